@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/coveooss/multilogger/reutils"
 	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/configstack"
 	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/coveooss/terragrunt/v2/util"
@@ -69,7 +71,44 @@ func parseTerragruntOptionsFromArgs(args []string) (*options.TerragruntOptions,
 		return util.RemoveElementFromList(strings.Split(result, string(",")), "")
 	}
 
-	workingDir := filepath.ToSlash(parse(optWorkingDir, currentDir))
+	parseAll := func(argName string) []string {
+		if err != nil {
+			return nil
+		}
+		var values []string
+		values, err = parseStringArgs(args, argName)
+		var result []string
+		for _, value := range values {
+			result = append(result, util.RemoveElementFromList(strings.Split(value, ","), "")...)
+		}
+		return result
+	}
+
+	// The working dir could be repeated (or the root folders listed in a stack file) to run the -all commands on several
+	// root folders at once, the current dir is then used as working dir
+	workingDir := filepath.ToSlash(currentDir)
+	var stackRoots []string
+	if err == nil {
+		stackRoots, err = parseStringArgs(args, optWorkingDir)
+	}
+	if stackFile := parse(optStackFile); stackFile != "" && err == nil {
+		var roots []string
+		roots, err = loadStackFile(stackFile)
+		stackRoots = append(stackRoots, roots...)
+	} else if len(stackRoots) == 1 {
+		workingDir, stackRoots = filepath.ToSlash(stackRoots[0]), nil
+	}
+	if len(stackRoots) > 0 && err == nil {
+		// Several root folders are only supported by the -all commands, other commands would silently run in the current dir
+		var command string
+		if len(args) > 0 {
+			command = args[0]
+		}
+		if command != getStackCommand && !strings.HasSuffix(command, multiModuleSuffix) {
+			err = tgerrors.WithStackTrace(errMultipleRootsNotSupported(command))
+		}
+	}
+
 	terragruntConfigPath := filepath.ToSlash(parse(optTerragruntConfig, os.Getenv(options.EnvConfig)))
 
 	if !strings.Contains(terragruntConfigPath, "/") {
@@ -82,6 +121,7 @@ func parseTerragruntOptionsFromArgs(args []string) (*options.TerragruntOptions,
 	opts.NonInteractive = parseBooleanArg(args, optNonInteractive, "", false)
 	opts.TerraformCliArgs = filterTerragruntArgs(args)
 	opts.WorkingDir = filepath.ToSlash(workingDir)
+	opts.StackRoots = stackRoots
 	opts.RunTerragrunt = runTerragrunt
 	opts.Source = parse(optTerragruntSource, os.Getenv(options.EnvSource))
 	opts.SourceUpdate = parseBooleanArg(args, optTerragruntSourceUpdate, options.EnvSourceUpdate, false)
@@ -94,9 +134,31 @@ func parseTerragruntOptionsFromArgs(args []string) (*options.TerragruntOptions,
 	opts.PreBootConfigurationPaths = parseList(optPreBootConfigs, os.Getenv(options.EnvPreBootConfigs))
 	opts.CheckSourceFolders = !parseBooleanArg(args, optIncludeEmptyFolders, options.EnvIncludeEmptyFolders, false)
 	opts.PluginsDirectory = parse(optPluginsDirectory, os.Getenv(options.EnvPluginsDirectory), "")
+	opts.ResumeRunID = parse(optResume)
+	opts.ReportFile = parse(optReport)
+	opts.ReportFormat = parse(optReportFormat)
+	opts.PlanDir = parse(optPlanDir)
+	opts.FromPlansDir = parse(optFromPlans)
+	opts.SchedulePolicy = parse(optSchedule, configstack.ScheduleCriticalPath)
+	opts.ExternalDependencies = parse(optExternalDependencies, configstack.ExternalDependenciesPrompt)
+	opts.FailFastInterrupt = parseBooleanArg(args, optFailFastInterrupt, "", false)
+	opts.FailFast = opts.FailFastInterrupt || parseBooleanArg(args, optFailFast, "", false)
+	opts.IncludeDirs = parseAll(optIncludeDir)
+	opts.ExcludeDirs = parseAll(optExcludeDir)
+	opts.AllowDestroy = parseAll(optAllowDestroy)
+	opts.WithDependencies = parseBooleanArg(args, optWithDependencies, "", false)
+	opts.WithDependents = parseBooleanArg(args, optWithDependents, "", false)
+	opts.InferDependencies = parseBooleanArg(args, optInferDependencies, "", false)
+	opts.NoDiscoveryCache = parseBooleanArg(args, optNoDiscoveryCache, "", false)
+	opts.AggregateOutput = parseBooleanArg(args, optAggregate, "", false)
+	opts.Explain = parseBooleanArg(args, optExplain, "", false)
+	opts.ExplainFormat = parse(optExplainFormat, explainFormatText)
 
 	flushDelay := parse(optFlushDelay, os.Getenv(options.EnvFlushDelay), "60s")
+	moduleTimeout := parse(optModuleTimeout)
+	runTimeout := parse(optRunTimeout)
 	nbWorkers := parse(optNbWorkers, os.Getenv(options.EnvWorkers), "10")
+	workersRampUp := parse(optWorkersRampUp, os.Getenv(options.EnvWorkersRampUp), "2.5s")
 	loggingLevel := parse(optLoggingLevel, os.Getenv(options.EnvLoggingLevel), logrus.InfoLevel.String())
 	fileLoggingDir := parse(optLoggingFileDir, os.Getenv(options.EnvLoggingFileDir))
 	fileLoggingLevel := parse(optLoggingFileLevel, os.Getenv(options.EnvLoggingFileLevel), logrus.DebugLevel.String())
@@ -109,10 +171,43 @@ func parseTerragruntOptionsFromArgs(args []string) (*options.TerragruntOptions,
 		return nil, fmt.Errorf("refresh delay must be expressed with unit (i.e. 45s)")
 	}
 
+	if moduleTimeout != "" {
+		if opts.ModuleTimeout, err = time.ParseDuration(moduleTimeout); err != nil {
+			return nil, fmt.Errorf("module timeout must be expressed with unit (i.e. 30m)")
+		}
+	}
+
+	if runTimeout != "" {
+		if opts.RunTimeout, err = time.ParseDuration(runTimeout); err != nil {
+			return nil, fmt.Errorf("run timeout must be expressed with unit (i.e. 2h)")
+		}
+	}
+
 	if opts.NbWorkers, err = strconv.Atoi(nbWorkers); err != nil {
 		return nil, fmt.Errorf("number of workers must be expressed as integer")
 	}
 
+	if opts.WorkersRampUp, err = time.ParseDuration(workersRampUp); err != nil {
+		return nil, fmt.Errorf("workers ramp up must be expressed with unit (i.e. 2s)")
+	}
+
+	if opts.ReportFormat != "" && !util.ListContainsElement([]string{configstack.ReportFormatJSON, configstack.ReportFormatJUnit}, opts.ReportFormat) {
+		return nil, fmt.Errorf("report format must be %s or %s", configstack.ReportFormatJSON, configstack.ReportFormatJUnit)
+	}
+
+	if !util.ListContainsElement([]string{explainFormatText, explainFormatJSON}, opts.ExplainFormat) {
+		return nil, fmt.Errorf("explain format must be %s or %s", explainFormatText, explainFormatJSON)
+	}
+
+	if !util.ListContainsElement([]string{configstack.ScheduleCriticalPath, configstack.ScheduleFIFO}, opts.SchedulePolicy) {
+		return nil, fmt.Errorf("schedule policy must be %s or %s", configstack.ScheduleCriticalPath, configstack.ScheduleFIFO)
+	}
+
+	externalDependenciesPolicies := []string{configstack.ExternalDependenciesPrompt, configstack.ExternalDependenciesSkip, configstack.ExternalDependenciesInclude, configstack.ExternalDependenciesFail}
+	if !util.ListContainsElement(externalDependenciesPolicies, opts.ExternalDependencies) {
+		return nil, fmt.Errorf("external dependencies policy must be one of %s", strings.Join(externalDependenciesPolicies, ", "))
+	}
+
 	opts.Logger.SetDefaultConsoleHookLevel(loggingLevel)
 	opts.Logger.SetColor(!util.ListContainsElement(opts.TerraformCliArgs, "-no-color"))
 	if fileLoggingDir != "" {
@@ -136,6 +231,41 @@ func parseTerragruntOptionsFromArgs(args []string) (*options.TerragruntOptions,
 	return opts, err
 }
 
+// Load the root folders listed in a stack file (one folder per line, relative to the folder of the stack file). The
+// empty lines and the lines starting with # are ignored.
+func loadStackFile(filename string) ([]string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+	var roots []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(filename), line)
+		}
+		roots = append(roots, filepath.ToSlash(line))
+	}
+	if len(roots) == 0 {
+		return nil, tgerrors.WithStackTrace(errEmptyStackFile(filename))
+	}
+	return roots, nil
+}
+
+type errMultipleRootsNotSupported string
+
+func (err errMultipleRootsNotSupported) Error() string {
+	return fmt.Sprintf("Several --%s or a --%s can only be specified with the -all commands (command: %q)", optWorkingDir, optStackFile, string(err))
+}
+
+type errEmptyStackFile string
+
+func (err errEmptyStackFile) Error() string {
+	return fmt.Sprintf("The stack file %s does not contain any folder", string(err))
+}
+
 func parseEnvironmentVariables(terragruntOptions *options.TerragruntOptions, environment []string) {
 	const tfPrefix = "TF_VAR_"
 	for i := 0; i < len(environment); i++ {
@@ -279,6 +409,22 @@ func parseStringArg(args []string, argName string, defaultValue string) (string,
 	return defaultValue, nil
 }
 
+// Find all the occurrences of a string argument (e.g. --foo "VALUE1" --foo "VALUE2") of the given name in the given list
+// of arguments. If one of them has no value, return an error.
+func parseStringArgs(args []string, argName string) ([]string, error) {
+	givenArg := fmt.Sprintf("--%s", argName)
+	var result []string
+	for i, arg := range args {
+		if arg == givenArg {
+			if (i + 1) >= len(args) {
+				return nil, tgerrors.WithStackTrace(ErrArgMissingValue(argName))
+			}
+			result = append(result, args[i+1])
+		}
+	}
+	return result, nil
+}
+
 // ErrArgMissingValue indicates that there is a missing argument value
 type ErrArgMissingValue string
 
@@ -2,12 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/configstack"
 	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/coveooss/terragrunt/v2/util"
@@ -117,6 +119,77 @@ func TestParseTerragruntOptionsFromArgs(t *testing.T) {
 			nil,
 		},
 
+		{
+			[]string{"plan-all", "--terragrunt-include-dir", "network", "--terragrunt-include-dir", "apps/*,tools", "--terragrunt-exclude-dir", "**/test", "--terragrunt-with-dependents"},
+			func() *options.TerragruntOptions {
+				terragruntOptions := mockOptions(util.JoinPath(workingDir, config.DefaultConfigName), workingDir, []string{}, false, "", false)
+				terragruntOptions.IncludeDirs = []string{"network", "apps/*", "tools"}
+				terragruntOptions.ExcludeDirs = []string{"**/test"}
+				terragruntOptions.WithDependents = true
+				return terragruntOptions
+			}(),
+			nil,
+		},
+
+		{
+			[]string{"destroy", "--terragrunt-allow-destroy", "network", "--terragrunt-allow-destroy", "apps/web/terragrunt.hcl"},
+			func() *options.TerragruntOptions {
+				terragruntOptions := mockOptions(util.JoinPath(workingDir, config.DefaultConfigName), workingDir, []string{"destroy"}, false, "", false)
+				terragruntOptions.AllowDestroy = []string{"network", "apps/web/terragrunt.hcl"}
+				return terragruntOptions
+			}(),
+			nil,
+		},
+
+		{
+			[]string{"plan-all", "--terragrunt-working-dir", "network", "--terragrunt-working-dir", "shared-services"},
+			func() *options.TerragruntOptions {
+				terragruntOptions := mockOptions(util.JoinPath(workingDir, config.DefaultConfigName), workingDir, []string{}, false, "", false)
+				terragruntOptions.StackRoots = []string{"network", "shared-services"}
+				return terragruntOptions
+			}(),
+			nil,
+		},
+
+		{
+			[]string{"plan-all", "--terragrunt-working-dir", "network"},
+			mockOptions(util.JoinPath("network", config.DefaultConfigName), "network", []string{}, false, "", false),
+			nil,
+		},
+
+		{
+			[]string{"apply-all", "--terragrunt-external-dependencies", "include"},
+			func() *options.TerragruntOptions {
+				terragruntOptions := mockOptions(util.JoinPath(workingDir, config.DefaultConfigName), workingDir, []string{}, false, "", false)
+				terragruntOptions.ExternalDependencies = configstack.ExternalDependenciesInclude
+				return terragruntOptions
+			}(),
+			nil,
+		},
+
+		{
+			[]string{"plan", "--terragrunt-explain", "--terragrunt-explain-format", "json"},
+			func() *options.TerragruntOptions {
+				terragruntOptions := mockOptions(util.JoinPath(workingDir, config.DefaultConfigName), workingDir, []string{"plan"}, false, "", false)
+				terragruntOptions.Explain = true
+				terragruntOptions.ExplainFormat = explainFormatJSON
+				return terragruntOptions
+			}(),
+			nil,
+		},
+
+		{
+			[]string{"--terragrunt-include-dir", "network", "--terragrunt-include-dir"},
+			nil,
+			ErrArgMissingValue("terragrunt-include-dir"),
+		},
+
+		{
+			[]string{"plan", "--terragrunt-working-dir", "network", "--terragrunt-working-dir", "shared-services"},
+			nil,
+			errMultipleRootsNotSupported("plan"),
+		},
+
 		{
 			[]string{"--terragrunt-config"},
 			nil,
@@ -238,6 +311,15 @@ func assertOptionsEqual(t *testing.T, expected options.TerragruntOptions, actual
 	assert.Equal(t, expected.ApplyTemplate, actual.ApplyTemplate, msgAndArgs...)
 	assert.Equal(t, expected.TemplateAdditionalPatterns, actual.TemplateAdditionalPatterns, msgAndArgs...)
 	assert.Equal(t, expected.BootConfigurationPaths, actual.BootConfigurationPaths, msgAndArgs...)
+	assert.Equal(t, expected.IncludeDirs, actual.IncludeDirs, msgAndArgs...)
+	assert.Equal(t, expected.ExcludeDirs, actual.ExcludeDirs, msgAndArgs...)
+	assert.Equal(t, expected.WithDependencies, actual.WithDependencies, msgAndArgs...)
+	assert.Equal(t, expected.WithDependents, actual.WithDependents, msgAndArgs...)
+	assert.Equal(t, expected.AllowDestroy, actual.AllowDestroy, msgAndArgs...)
+	assert.Equal(t, expected.StackRoots, actual.StackRoots, msgAndArgs...)
+	assert.Equal(t, expected.ExternalDependencies, actual.ExternalDependencies, msgAndArgs...)
+	assert.Equal(t, expected.Explain, actual.Explain, msgAndArgs...)
+	assert.Equal(t, expected.ExplainFormat, actual.ExplainFormat, msgAndArgs...)
 }
 
 func mockOptions(terragruntConfigPath string, workingDir string, terraformCliArgs []string, nonInteractive bool, terragruntSource string, ignoreDependencyErrors bool) *options.TerragruntOptions {
@@ -248,10 +330,40 @@ func mockOptions(terragruntConfigPath string, workingDir string, terraformCliArg
 	opts.NonInteractive = nonInteractive
 	opts.Source = terragruntSource
 	opts.IgnoreDependencyErrors = ignoreDependencyErrors
+	opts.ExternalDependencies = configstack.ExternalDependenciesPrompt
+	opts.ExplainFormat = explainFormatText
 
 	return opts
 }
 
+func TestLoadStackFile(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "stack-file")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	stackFile := filepath.Join(folder, "stack")
+	assert.NoError(t, ioutil.WriteFile(stackFile, []byte("# Roots of the stack\nnetwork\n\n  shared-services  \n/opt/stack\n"), 0644))
+	roots, err := loadStackFile(stackFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.ToSlash(filepath.Join(folder, "network")),
+		filepath.ToSlash(filepath.Join(folder, "shared-services")),
+		"/opt/stack",
+	}, roots)
+
+	// The stack file can only be used with the -all commands
+	_, err = parseTerragruntOptionsFromArgs([]string{"plan-all", "--terragrunt-stack-file", stackFile})
+	assert.NoError(t, err)
+	_, err = parseTerragruntOptionsFromArgs([]string{"plan", "--terragrunt-stack-file", stackFile})
+	assert.True(t, tgerrors.IsError(err, errMultipleRootsNotSupported("plan")))
+
+	assert.NoError(t, ioutil.WriteFile(stackFile, []byte("# No roots\n"), 0644))
+	_, err = loadStackFile(stackFile)
+	assert.True(t, tgerrors.IsError(err, errEmptyStackFile(stackFile)))
+}
+
 func TestFilterTerragruntArgs(t *testing.T) {
 	t.Parallel()
 
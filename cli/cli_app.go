@@ -1,12 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/template"
 	"github.com/coveooss/gotemplate/v3/utils"
@@ -40,6 +43,8 @@ const (
 	optLoggingFileLevel                 = "terragrunt-logging-file-level"
 	optFlushDelay                       = "terragrunt-flush-delay"
 	optNbWorkers                        = "terragrunt-workers"
+	optWorkersRampUp                    = "terragrunt-workers-ramp-up"
+	optSchedule                         = "terragrunt-schedule"
 	optAWSProfile                       = "profile"
 	optApplyTemplate                    = "terragrunt-apply-template"
 	optTemplatePatterns                 = "terragrunt-template-patterns"
@@ -47,10 +52,31 @@ const (
 	optPreBootConfigs                   = "terragrunt-pre-boot-configs"
 	optIncludeEmptyFolders              = "terragrunt-include-empty-folders"
 	optPluginsDirectory                 = "terragrunt-plugins-directory"
+	optResume                           = "terragrunt-resume"
+	optReport                           = "terragrunt-report"
+	optReportFormat                     = "terragrunt-report-format"
+	optFailFast                         = "terragrunt-fail-fast"
+	optFailFastInterrupt                = "terragrunt-fail-fast-interrupt"
+	optIncludeDir                       = "terragrunt-include-dir"
+	optExcludeDir                       = "terragrunt-exclude-dir"
+	optWithDependencies                 = "terragrunt-with-dependencies"
+	optWithDependents                   = "terragrunt-with-dependents"
+	optModuleTimeout                    = "terragrunt-module-timeout"
+	optRunTimeout                       = "terragrunt-run-timeout"
+	optPlanDir                          = "terragrunt-plan-dir"
+	optFromPlans                        = "terragrunt-from-plans"
+	optInferDependencies                = "terragrunt-infer-dependencies"
+	optAllowDestroy                     = "terragrunt-allow-destroy"
+	optNoDiscoveryCache                 = "terragrunt-no-discovery-cache"
+	optStackFile                        = "terragrunt-stack-file"
+	optExternalDependencies             = "terragrunt-external-dependencies"
+	optAggregate                        = "terragrunt-aggregate"
+	optExplain                          = "terragrunt-explain"
+	optExplainFormat                    = "terragrunt-explain-format"
 )
 
-var allTerragruntBooleanOpts = []string{optNonInteractive, optTerragruntSourceUpdate, optTerragruntIgnoreDependencyErrors, optApplyTemplate, optIncludeEmptyFolders}
-var allTerragruntStringOpts = []string{optTerragruntConfig, optTerragruntTFPath, optWorkingDir, optTerragruntSource, optLoggingLevel, optAWSProfile, optApprovalHandler, optFlushDelay, optNbWorkers, optTemplatePatterns, optBootConfigs, optPreBootConfigs, optLoggingFileDir, optLoggingFileLevel}
+var allTerragruntBooleanOpts = []string{optNonInteractive, optTerragruntSourceUpdate, optTerragruntIgnoreDependencyErrors, optApplyTemplate, optIncludeEmptyFolders, optFailFast, optFailFastInterrupt, optWithDependencies, optWithDependents, optInferDependencies, optNoDiscoveryCache, optAggregate, optExplain}
+var allTerragruntStringOpts = []string{optTerragruntConfig, optTerragruntTFPath, optWorkingDir, optTerragruntSource, optLoggingLevel, optAWSProfile, optApprovalHandler, optFlushDelay, optNbWorkers, optWorkersRampUp, optSchedule, optTemplatePatterns, optBootConfigs, optPreBootConfigs, optLoggingFileDir, optLoggingFileLevel, optResume, optReport, optReportFormat, optIncludeDir, optExcludeDir, optModuleTimeout, optRunTimeout, optPlanDir, optFromPlans, optAllowDestroy, optStackFile, optExternalDependencies, optExplainFormat}
 
 const multiModuleSuffix = "-all"
 const cmdInit = "init"
@@ -97,7 +123,7 @@ COMMANDS:
 
    get-doc [options...] [filters...] Print the documentation of all extra_arguments, import_files, pre_hook, post_hook and extra_command.
    get-versions                      Get all versions of underlying tools (including extra_command).
-   get-stack [options]               Get the list of stack to execute sorted by dependency order.
+   get-stack [options]               Get the list of stack to execute sorted by dependency order (or its dependency graph and execution layers).
 
    -all operations:
    plan-all                          Display the plans of a 'stack' by running 'terragrunt plan' in each subfolder (with a summary at the end).
@@ -113,7 +139,8 @@ GLOBAL OPTIONS:
    terragrunt-config                    Path to the Terragrunt config file. Default is terragrunt.hcl.
    terragrunt-tfpath                    Path to the Terraform binary. Default is terraform (on PATH).
    terragrunt-non-interactive           Assume "yes" for all prompts.
-   terragrunt-working-dir               The path to the Terraform templates. Default is current directory.
+   terragrunt-working-dir               The path to the Terraform templates. Default is current directory (could be repeated to run *-all commands on several root folders).
+   terragrunt-stack-file                File listing the root folders (one per line) in which *-all commands look for modules.
    terragrunt-source                    Download Terraform configurations from the specified source into a temporary folder, and run Terraform in that temporary folder.
    terragrunt-source-update             Delete the contents of the temporary folder to clear out any old, cached source code before downloading new source code into it.
    terragrunt-ignore-dependency-errors  *-all commands continue processing components even if a dependency fails.
@@ -123,7 +150,29 @@ GLOBAL OPTIONS:
    terragrunt-approval                  Program to use for approval. {val} will be replaced by the current terragrunt output. Ex: approval.py --value {val}.
    terragrunt-flush-delay               Maximum delay on -all commands before printing out traces (INFO) indicating that the process is still alive (default 60s).
    terragrunt-workers                   Number of concurrent workers (default 10).
+   terragrunt-workers-ramp-up           Delay between the start of each worker on *-all commands to avoid throttling (default 2.5s, 0 = start all workers immediately).
+   terragrunt-schedule                  Order in which the ready modules get a worker: critical-path (longest chain of dependent modules first, based on previous durations) or fifo (default critical-path).
+   terragrunt-module-timeout            Maximum duration of the commands of each module (i.e. 30m) before they get interrupted (overridden by the timeout defined in the config).
+   terragrunt-run-timeout               Maximum duration of *-all commands (i.e. 2h), the running modules are interrupted and the pending ones are cancelled.
+   terragrunt-fail-fast                 *-all commands stop launching new modules as soon as a module fails (pending modules are cancelled).
+   terragrunt-fail-fast-interrupt       Same as terragrunt-fail-fast, but also interrupts the modules that are currently running.
    terragrunt-include-empty-folders     Do not check if source folders contains terraform files to consider them as part of the stack.
+   terragrunt-include-dir               *-all commands only consider the modules matching the glob pattern (could be repeated, ** matches any number of folders).
+   terragrunt-exclude-dir               *-all commands ignore the modules matching the glob pattern (could be repeated). Patterns could also be defined in a .terragruntignore file.
+   terragrunt-with-dependencies         Also include the modules on which the selected modules depend (recursively).
+   terragrunt-with-dependents           Also include the modules that depend on the selected modules (recursively).
+   terragrunt-aggregate                 output-all prints the outputs of all modules as a single JSON document (keyed by module path).
+   terragrunt-external-dependencies     Policy for the dependencies outside of the stack: prompt (default), skip, include or fail.
+   terragrunt-infer-dependencies        Add the dependencies found by matching the terraform_remote_state data sources with the remote state of the other modules.
+   terragrunt-no-discovery-cache        Parse the configuration of every module of the stack instead of reusing the result of the previous runs for unchanged modules.
+   terragrunt-report                    Write a report of *-all commands (status, exit code, errors, changes and timings of each module) in the specified file.
+   terragrunt-report-format             Format of the report: json or junit (default is determined by the file extension, .xml = junit).
+   terragrunt-plan-dir                  plan-all saves the plan of each module in the specified folder (with a manifest used by terragrunt-from-plans).
+   terragrunt-from-plans                apply-all applies the plans saved by plan-all in the specified folder (modules changed since the plan are refused).
+   terragrunt-allow-destroy             Allow destroying the module at the specified path even if it is protected by prevent_destroy (could be repeated).
+   terragrunt-explain                   Print what would be executed on the module(s) (source, temp folder, arguments, hooks, approval, roles) without running terraform or any hook.
+   terragrunt-explain-format            Format of the explanations: text or json (default text).
+   terragrunt-resume                    Resume a previous *-all run of the last 7 days (identified by its TERRAGRUNT_RUN_ID), skipping the modules that already succeeded.
    profile                              Specify an AWS profile to use.
 
 ENVIRONMENT VARIABLES:
@@ -258,6 +307,9 @@ func runCommand(command string, terragruntOptions *options.TerragruntOptions) (f
 	if isMultiModules {
 		return runMultiModuleCommand(command, terragruntOptions)
 	}
+	if terragruntOptions.Explain {
+		return explain(terragruntOptions)
+	}
 	return runTerragrunt(terragruntOptions)
 }
 
@@ -287,6 +339,18 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 		return err
 	}
 
+	if len(terragruntOptions.DependencyChain) > 0 {
+		// We are only fetching the outputs of the module for a dependency block, so the hooks must not be executed.
+		// The config is copied since it is shared by all the parsings of the module.
+		outputsOnly := *conf
+		outputsOnly.PreHooks, outputsOnly.PostHooks = nil, nil
+		conf = &outputsOnly
+	}
+
+	if util.IndexOrDefault(terragruntOptions.TerraformCliArgs, 0, "") == "destroy" && conf.IsDestroyPrevented() && !isDestroyAllowed(terragruntOptions) {
+		return errPreventDestroy(terragruntOptions.WorkingDir)
+	}
+
 	sourceURL, hasSourceURL := getTerraformSourceURL(terragruntOptions, conf)
 	if sourceURL == "" {
 		sourceURL = terragruntOptions.WorkingDir
@@ -302,6 +366,27 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 		return runHandler(terragruntOptions, conf)
 	}
 
+	if terragruntOptions.RunContext == nil {
+		// The timeouts are already handled by the stack if we are running a -all command
+		timeout, err := conf.GetTimeout(terragruntOptions.ModuleTimeout)
+		if err != nil {
+			return err
+		}
+		if terragruntOptions.RunTimeout > 0 && (timeout == 0 || terragruntOptions.RunTimeout < timeout) {
+			timeout = terragruntOptions.RunTimeout
+		}
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			terragruntOptions.RunContext = ctx
+			defer func() {
+				if finalStatus != nil && ctx.Err() == context.DeadlineExceeded {
+					finalStatus = errTimeout{timeout, finalStatus}
+				}
+			}()
+		}
+	}
+
 	// Check if the current command is an extra command
 	actualCommand := conf.ExtraCommands.ActualCommand(terragruntOptions.TerraformCliArgs[0])
 	ignoreError := actualCommand.Extra != nil && actualCommand.Extra.IgnoreError
@@ -336,6 +421,7 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 	}
 
 	// Copy the deployment files to the working directory
+	terragruntOptions.SetPhase(options.PhaseDownload)
 	terraformSource, err := processTerraformSource(sourceURL, terragruntOptions)
 	if stopOnError(err) {
 		return err
@@ -358,24 +444,15 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 
 	// Applying the extra arguments
 	if len(conf.ExtraArgs) > 0 {
-		commandLength := 1
-		if util.ListContainsElement(terraformCommandsWithSubCommand, terragruntOptions.TerraformCliArgs[0]) {
-			commandLength = 2
-		}
-
-		// Options must be inserted after command but before the other args command is either 1 word or 2 words
-		var args []string
-		args = append(args, terragruntOptions.TerraformCliArgs[:commandLength]...)
 		extraArgs, err := conf.ExtraArguments(sourceURL)
 		if stopOnError(err) {
 			return
 		}
-
-		args = append(args, extraArgs...)
-		if commandLength <= len(terragruntOptions.TerraformCliArgs) {
-			args = append(args, terragruntOptions.TerraformCliArgs[commandLength:]...)
+		if terragruntOptions.FromPlansDir != "" {
+			// Terraform refuses the variables when a saved plan is applied, the variables are already in the plan
+			extraArgs = removeVariableArgs(extraArgs)
 		}
-		terragruntOptions.TerraformCliArgs = args
+		terragruntOptions.TerraformCliArgs = insertExtraArgs(terragruntOptions.TerraformCliArgs, extraArgs)
 	}
 
 	// Determinate if the project should be ignored
@@ -391,6 +468,7 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 	// Import the required files in the temporary folder and copy the temporary imported file in the
 	// working folder. We did not put them directly into the folder because terraform init would complain
 	// if there are already terraform files in the target folder
+	terragruntOptions.SetPhase(options.PhaseImportFiles)
 	if err := conf.ImportFiles.Run(err); stopOnError(err) {
 		return
 	}
@@ -552,6 +630,7 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 	}
 
 	terragruntOptions.Logger.Info("Running Terraform init")
+	terragruntOptions.SetPhase(options.PhaseInit)
 	initArgs := []string{"init", "-reconfigure"}
 	if conf.RemoteState != nil {
 		initArgs = append(initArgs, conf.RemoteState.ToTerraformInitArgs()...)
@@ -612,13 +691,23 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 		// We restore back the name of the command since it may have been temporary changed to support state file initialization and get modules
 		terragruntOptions.TerraformCliArgs[0] = actualCommand.Command
 
-		cmd = shell.NewTFCmd(terragruntOptions).Args(terragruntOptions.TerraformCliArgs...)
+		var retryPolicy *shell.RetryPolicy
+		if retryPolicy, err = conf.Retry.Policy(); stopOnError(err) {
+			return
+		}
+		cmd = shell.NewTFCmd(terragruntOptions).Args(terragruntOptions.TerraformCliArgs...).WithRetryPolicy(retryPolicy)
 	}
-	if shouldBeApproved, approvalConfig := conf.ApprovalConfig.ShouldBeApproved(actualCommand.Command); shouldBeApproved {
+	if approvalConfig := commandApproval(conf, actualCommand.Command, terragruntOptions); approvalConfig != nil {
 		cmd = cmd.Expect(approvalConfig.ExpectStatements, approvalConfig.CompletedStatements)
 	}
+	if terragruntOptions.CommandWriter != nil {
+		cmd.Stdout = terragruntOptions.CommandWriter
+	}
 	cmd.LogLevel = logrus.InfoLevel
-	err = shell.FilterPlanError(cmd.Run(), actualCommand.Command)
+	terragruntOptions.CommandWorkingDir = terragruntOptions.WorkingDir
+	terragruntOptions.SetPhase(options.PhaseCommand)
+	terragruntOptions.Attempts, err = cmd.RunWithAttempts()
+	err = shell.FilterPlanError(err, actualCommand.Command)
 
 	exitCode, errCode := shell.GetExitCode(err)
 	if errCode != nil {
@@ -632,14 +721,42 @@ func runTerragrunt(terragruntOptions *options.TerragruntOptions) (finalStatus er
 	return
 }
 
+// Returns the approval config of the command (nil if the command doesn't have to be approved). Terraform never prompts
+// when it applies a plan saved by plan-all, so there is nothing to approve in that case.
+func commandApproval(conf *config.TerragruntConfig, command string, terragruntOptions *options.TerragruntOptions) *config.ApprovalConfig {
+	if terragruntOptions.FromPlansDir != "" {
+		return nil
+	}
+	if shouldBeApproved, approvalConfig := conf.ApprovalConfig.ShouldBeApproved(command); shouldBeApproved {
+		return approvalConfig
+	}
+	return nil
+}
+
 // Execute a command that affects multiple Terraform modules, such as the apply-all or destroy-all command.
-func runMultiModuleCommand(command string, terragruntOptions *options.TerragruntOptions) error {
+func runMultiModuleCommand(command string, terragruntOptions *options.TerragruntOptions) (err error) {
 	realCommand := strings.TrimSuffix(command, multiModuleSuffix)
 	terragruntOptions.Context["Command"] = realCommand
 
 	if command == getStackCommand {
 		return getStack(terragruntOptions)
-	} else if strings.HasPrefix(command, "plan-") {
+	}
+
+	if terragruntOptions.Explain {
+		// Nothing is executed, so the before_all_hook and after_all_hook are not run either
+		return explainAll(realCommand, terragruntOptions)
+	}
+
+	// The before_all_hook and after_all_hook of the working directory config are executed once around the whole stack
+	stackConfig, stackOptions := readStackConfig(realCommand, terragruntOptions)
+	if stackConfig != nil {
+		if _, err = stackConfig.BeforeAllHooks.Run(nil); err != nil {
+			return err
+		}
+		defer func() { err = runAfterAllHooks(stackConfig, stackOptions, err) }()
+	}
+
+	if strings.HasPrefix(command, "plan-") {
 		return planAll(realCommand, terragruntOptions)
 	} else if strings.HasPrefix(command, "apply-") {
 		return applyAll(realCommand, terragruntOptions)
@@ -653,6 +770,57 @@ func runMultiModuleCommand(command string, terragruntOptions *options.Terragrunt
 	return tgerrors.WithStackTrace(unrecognizedCommand(command))
 }
 
+// Read the config of the working directory to get the hooks that must be executed around the stack (nil if there are none)
+func readStackConfig(command string, terragruntOptions *options.TerragruntOptions) (*config.TerragruntConfig, *options.TerragruntOptions) {
+	if !util.FileExists(terragruntOptions.TerragruntConfigPath) {
+		return nil, nil
+	}
+	stackOptions := terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	stackOptions.SkipDependencyOutputs = true
+	stackOptions.TerraformCliArgs = []string{command}
+	stackOptions.Env[options.EnvCommand] = command
+	_, conf, err := config.ParseConfigFile(stackOptions, config.IncludeConfig{Path: terragruntOptions.TerragruntConfigPath})
+	if err != nil {
+		// The config of the working directory may only be intended to be included by the modules of the stack
+		terragruntOptions.Logger.Warningf("Unable to read the before_all_hook and after_all_hook from %s: %v", terragruntOptions.TerragruntConfigPath, err)
+		return nil, nil
+	}
+	if len(conf.BeforeAllHooks)+len(conf.AfterAllHooks) == 0 {
+		return nil, nil
+	}
+	return conf, stackOptions
+}
+
+// Run the after_all_hook with the aggregated status of the stack in TERRAGRUNT_STATUS/TERRAGRUNT_ERROR and the path of the report
+// in TERRAGRUNT_REPORT. The error of the stack has precedence over the error of the hooks.
+func runAfterAllHooks(stackConfig *config.TerragruntConfig, stackOptions *options.TerragruntOptions, status error) error {
+	if len(stackConfig.AfterAllHooks) == 0 {
+		return status
+	}
+	// If there is an error but it is in fact a plan status, we run the hooks normally (the plan status is still returned)
+	hookStatus := status
+	if _, planStatusError := tgerrors.Unwrap(status).(tgerrors.PlanWithChanges); planStatusError {
+		hookStatus = nil
+	}
+
+	exitCode, errCode := shell.GetExitCode(hookStatus)
+	if errCode != nil {
+		exitCode = -1
+	}
+	stackOptions.SetStatus(exitCode, hookStatus)
+	if stackOptions.ReportFile != "" {
+		stackOptions.Env[options.EnvReport], _ = filepath.Abs(stackOptions.ReportFile)
+	}
+
+	if _, err := stackConfig.AfterAllHooks.Run(hookStatus); err != nil && hookStatus == nil {
+		// An error in the hooks has precedence over the plan status, but not the changes reported by the hooks
+		if _, planStatusError := tgerrors.Unwrap(err).(tgerrors.PlanWithChanges); !planStatusError || status == nil {
+			return err
+		}
+	}
+	return status
+}
+
 // If the user entered a Terraform command that uses state (e.g. plan, apply), make sure remote state is configured
 // before running the command.
 func configureRemoteState(remoteState *remote.State, terragruntOptions *options.TerragruntOptions) error {
@@ -698,12 +866,17 @@ func applyAll(command string, terragruntOptions *options.TerragruntOptions) erro
 	}
 
 	prompt := fmt.Sprintf("%s\nAre you sure you want to run 'terragrunt apply' in each folder of the stack described above?", stack)
+	if terragruntOptions.FromPlansDir != "" {
+		prompt = fmt.Sprintf("%s\nAre you sure you want to apply the plans saved in %s for the stack described above?", stack, terragruntOptions.FromPlansDir)
+	}
 	shouldApplyAll, err := shell.PromptUserForYesNo(prompt, terragruntOptions)
 	if err != nil {
 		return err
 	}
 
-	if shouldApplyAll {
+	if shouldApplyAll && terragruntOptions.FromPlansDir != "" {
+		return stack.ApplyPlans(command, terragruntOptions.FromPlansDir, terragruntOptions)
+	} else if shouldApplyAll {
 		return stack.RunAll([]string{command, "-input=false"}, terragruntOptions, configstack.NormalOrder)
 	}
 
@@ -719,6 +892,16 @@ func destroyAll(command string, terragruntOptions *options.TerragruntOptions) er
 	}
 
 	prompt := fmt.Sprintf("%s\nWARNING: Are you sure you want to run `terragrunt destroy` in each folder of the stack described above? There is no undo!", stack)
+	var protected []string
+	for _, module := range stack.Modules {
+		if module.Config.IsDestroyPrevented() && !isDestroyAllowed(module.TerragruntOptions) {
+			protected = append(protected, util.GetPathRelativeToWorkingDir(module.Path))
+		}
+	}
+	if len(protected) > 0 {
+		sort.Strings(protected)
+		prompt = fmt.Sprintf("%s\nThe following modules are protected by prevent_destroy and will be refused: %s", prompt, strings.Join(protected, ", "))
+	}
 	shouldDestroyAll, err := shell.PromptUserForYesNo(prompt, terragruntOptions)
 	if err != nil {
 		return err
@@ -743,6 +926,54 @@ func outputAll(command string, terragruntOptions *options.TerragruntOptions) err
 	return stack.Output(command, terragruntOptions)
 }
 
+// Insert the extra arguments in the terraform arguments. Options must be inserted after command but before the other
+// args, the command is either 1 word or 2 words.
+func insertExtraArgs(terraformArgs, extraArgs []string) []string {
+	commandLength := 1
+	if util.ListContainsElement(terraformCommandsWithSubCommand, util.IndexOrDefault(terraformArgs, 0, "")) {
+		commandLength = 2
+	}
+	if commandLength > len(terraformArgs) {
+		commandLength = len(terraformArgs)
+	}
+
+	args := make([]string, 0, len(terraformArgs)+len(extraArgs))
+	args = append(args, terraformArgs[:commandLength]...)
+	args = append(args, extraArgs...)
+	return append(args, terraformArgs[commandLength:]...)
+}
+
+// Returns the arguments without the variables definitions (-var and -var-file)
+func removeVariableArgs(args []string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-var" || args[i] == "-var-file":
+			// The value is in the next argument
+			i++
+		case strings.HasPrefix(args[i], "-var=") || strings.HasPrefix(args[i], "-var-file="):
+		default:
+			result = append(result, args[i])
+		}
+	}
+	return result
+}
+
+// Returns true if the user explicitly allowed the destruction of the module with --terragrunt-allow-destroy
+func isDestroyAllowed(terragruntOptions *options.TerragruntOptions) bool {
+	// The user could either specify the folder or the config file of the module
+	modulePaths, err := util.CanonicalPaths([]string{terragruntOptions.WorkingDir, terragruntOptions.TerragruntConfigPath}, "")
+	if err != nil {
+		return false
+	}
+	for _, path := range terragruntOptions.AllowDestroy {
+		if allowedPath, err := util.CanonicalPath(path, ""); err == nil && util.ListContainsElement(modulePaths, allowedPath) {
+			return true
+		}
+	}
+	return false
+}
+
 // Custom error types
 
 type unrecognizedCommand string
@@ -750,3 +981,25 @@ type unrecognizedCommand string
 func (commandName unrecognizedCommand) Error() string {
 	return fmt.Sprintf("Unrecognized command: %s", string(commandName))
 }
+
+type errPreventDestroy string
+
+func (path errPreventDestroy) Error() string {
+	return fmt.Sprintf("Module %s is protected by prevent_destroy, use --%s %s to destroy it anyway", string(path), optAllowDestroy, util.GetPathRelativeToWorkingDir(string(path)))
+}
+
+type errTimeout struct {
+	timeout time.Duration
+	err     error
+}
+
+func (err errTimeout) Error() string {
+	return fmt.Sprintf("Timed out after %v: %v", err.timeout, err.err)
+}
+
+func (err errTimeout) ExitStatus() (int, error) {
+	if exitCode, e := shell.GetExitCode(err.err); e == nil && exitCode != 0 {
+		return exitCode, nil
+	}
+	return 1, nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAfterAllHooks(t *testing.T) {
+	t.Parallel()
+
+	stackError := errors.New("stack error")
+	tests := []struct {
+		name       string
+		hook       string
+		status     error
+		wantStatus bool // The original status is expected
+		wantErr    bool // An error from the hook is expected
+	}{
+		{"Success", "true", nil, true, false},
+		{"Plan with changes", "true", tgerrors.PlanWithChanges{}, true, false},
+		{"Stack error", "true", stackError, true, false},
+		{"Hook error", "false", nil, false, true},
+		{"Hook error with plan changes", "false", tgerrors.PlanWithChanges{}, false, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			folder, err := ioutil.TempDir("", "after-all-hooks")
+			assert.NoError(t, err)
+			defer os.RemoveAll(folder)
+			configPath := filepath.Join(folder, "terragrunt.hcl")
+			content := `after_all_hook "notify" {
+				command       = "` + tt.hook + `"
+				run_on_errors = true
+			}`
+			assert.NoError(t, ioutil.WriteFile(configPath, []byte(content), 0644))
+
+			stackConfig, stackOptions := readStackConfig("plan", options.NewTerragruntOptionsForTest(configPath))
+			if !assert.NotNil(t, stackConfig) {
+				return
+			}
+			err = runAfterAllHooks(stackConfig, stackOptions, tt.status)
+			if tt.wantStatus {
+				assert.Equal(t, tt.status, err)
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.NotEqual(t, tt.status, err)
+			}
+		})
+	}
+}
+
+func TestCommandApproval(t *testing.T) {
+	t.Parallel()
+
+	conf := &config.TerragruntConfig{ApprovalConfig: config.ApprovalConfigList{
+		{Commands: []string{"apply"}, ExpectStatements: []string{"Enter a value:"}, CompletedStatements: []string{"Apply complete!"}},
+	}}
+	tests := []struct {
+		name         string
+		command      string
+		fromPlansDir string
+		wantApproval bool
+	}{
+		{"Apply", "apply", "", true},
+		{"Plan", "plan", "", false},
+		{"Apply saved plans", "apply", "plans", false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			terragruntOptions := options.NewTerragruntOptionsForTest("terragrunt.hcl")
+			terragruntOptions.FromPlansDir = tt.fromPlansDir
+			assert.Equal(t, tt.wantApproval, commandApproval(conf, tt.command, terragruntOptions) != nil)
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/configstack"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Supported formats for --terragrunt-explain
+const (
+	explainFormatText = "text"
+	explainFormatJSON = "json"
+)
+
+var explainSeparator = strings.Repeat("-", 132)
+
+// The phases in which the hooks are executed (in execution order)
+var explainHookPhases = []string{"before_imports", "before_init_state", "after_init_state", "post"}
+
+// moduleExplanation describes everything runTerragrunt would do on a module
+type moduleExplanation struct {
+	Module         string                     `json:"module"`
+	ConfigFile     string                     `json:"config_file"`
+	Command        string                     `json:"command"`
+	Skipped        string                     `json:"skipped,omitempty"`
+	SourceFolder   string                     `json:"source_folder,omitempty"`
+	TempFolder     string                     `json:"temp_folder,omitempty"`
+	ExtraArguments []string                   `json:"extra_arguments,omitempty"`
+	VarFiles       []string                   `json:"var_files,omitempty"`
+	ImportFiles    []explainedImportFiles     `json:"import_files,omitempty"`
+	Hooks          map[string][]explainedHook `json:"hooks,omitempty"`
+	Approval       *explainedApproval         `json:"approval,omitempty"`
+	AssumeRole     []string                   `json:"assume_role,omitempty"`
+	InitArgs       []string                   `json:"init_args,omitempty"`
+	CommandArgs    []string                   `json:"command_args,omitempty"`
+}
+
+type explainedImportFiles struct {
+	Name   string   `json:"name"`
+	Source string   `json:"source,omitempty"`
+	Files  []string `json:"files"`
+	Target string   `json:"target,omitempty"`
+}
+
+type explainedHook struct {
+	Name        string   `json:"name"`
+	Command     string   `json:"command"`
+	Arguments   []string `json:"arguments,omitempty"`
+	IgnoreError bool     `json:"ignore_error,omitempty"`
+	RunOnErrors bool     `json:"run_on_errors,omitempty"`
+}
+
+type explainedApproval struct {
+	Name                string   `json:"name"`
+	ExpectStatements    []string `json:"expect_statements"`
+	CompletedStatements []string `json:"completed_statements"`
+}
+
+// Print what terragrunt would execute on the module without running terraform or any hook
+func explain(terragruntOptions *options.TerragruntOptions) error {
+	explanation, err := explainModule(terragruntOptions)
+	if err != nil {
+		return err
+	}
+	return printExplanations(terragruntOptions, []*moduleExplanation{explanation})
+}
+
+// Print what terragrunt would execute on each module of the stack (in the order they would be started)
+func explainAll(command string, terragruntOptions *options.TerragruntOptions) error {
+	stack, err := configstack.FindStackInSubfolders(terragruntOptions)
+	if err != nil {
+		return err
+	}
+	stack.SortModules()
+
+	explanations := make([]*moduleExplanation, 0, len(stack.Modules))
+	for _, module := range stack.Modules {
+		moduleOptions := module.TerragruntOptions.Clone(module.TerragruntOptions.TerragruntConfigPath)
+		moduleOptions.TerraformCliArgs = append(stackCommandArgs(command), moduleOptions.TerraformCliArgs...)
+		if module.AssumeAlreadyApplied {
+			explanations = append(explanations, &moduleExplanation{
+				Module:     module.Path,
+				ConfigFile: moduleOptions.TerragruntConfigPath,
+				Command:    command,
+				Skipped:    "external dependency assumed to be already applied",
+			})
+			continue
+		}
+		explanation, err := explainModule(moduleOptions)
+		if err != nil {
+			return err
+		}
+		explanations = append(explanations, explanation)
+	}
+	if strings.HasPrefix(command, "destroy") {
+		// The modules are destroyed in the reverse order
+		for i, j := 0, len(explanations)-1; i < j; i, j = i+1, j-1 {
+			explanations[i], explanations[j] = explanations[j], explanations[i]
+		}
+	}
+	return printExplanations(terragruntOptions, explanations)
+}
+
+// Returns the arguments added by the -all commands before the arguments supplied by the user
+func stackCommandArgs(command string) []string {
+	switch command {
+	case "apply":
+		return []string{command, "-input=false"}
+	case "destroy":
+		return []string{command, "-auto-approve", "-input=false"}
+	}
+	return []string{command}
+}
+
+// Resolve everything runTerragrunt does on the module. The outputs of the dependencies are not fetched and the source is
+// not downloaded, so the var files are searched in the module folder and in the source folder.
+func explainModule(terragruntOptions *options.TerragruntOptions) (*moduleExplanation, error) {
+	terragruntOptions = terragruntOptions.Clone(terragruntOptions.TerragruntConfigPath)
+	terragruntOptions.SkipDependencyOutputs = true
+	// The arguments are shared with the original options and are modified the same way runTerragrunt does
+	terragruntOptions.TerraformCliArgs = append([]string{}, terragruntOptions.TerraformCliArgs...)
+	command := util.IndexOrDefault(terragruntOptions.TerraformCliArgs, 0, "")
+	result := &moduleExplanation{
+		Module:     terragruntOptions.WorkingDir,
+		ConfigFile: terragruntOptions.TerragruntConfigPath,
+		Command:    command,
+	}
+
+	for _, ignoreFile := range []string{options.IgnoreFile, options.IgnoreFileNonInteractive} {
+		if (ignoreFile == options.IgnoreFile || terragruntOptions.NonInteractive) && util.FileExists(filepath.Join(terragruntOptions.WorkingDir, ignoreFile)) {
+			result.Skipped = fmt.Sprintf("folder ignored because %s is present", ignoreFile)
+			return result, nil
+		}
+	}
+
+	conf, err := config.ReadTerragruntConfig(terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if command == "destroy" && conf.IsDestroyPrevented() && !isDestroyAllowed(terragruntOptions) {
+		result.Skipped = errPreventDestroy(terragruntOptions.WorkingDir).Error()
+		return result, nil
+	}
+
+	sourceURL, hasSourceURL := getTerraformSourceURL(terragruntOptions, conf)
+	if sourceURL == "" {
+		sourceURL = terragruntOptions.WorkingDir
+	}
+	result.SourceFolder = sourceURL
+
+	actualCommand := conf.ExtraCommands.ActualCommand(command)
+	terragruntOptions.Env[options.EnvCommand] = command
+
+	if conf.UniquenessCriteria != nil {
+		terragruntOptions.UniquenessCriteria = *conf.UniquenessCriteria
+	}
+	terraformSource, err := processTerraformSource(sourceURL, terragruntOptions)
+	if err != nil {
+		return nil, err
+	}
+	if hasSourceURL || len(conf.ImportFiles)+len(conf.ExportVariablesConfigs)+len(conf.ExportConfigConfigs) > 0 {
+		result.TempFolder = terraformSource.WorkingDir
+	}
+
+	if len(conf.ExtraArgs) > 0 {
+		extraArgs, err := conf.ExtraArguments(sourceURL)
+		if err != nil {
+			return nil, err
+		}
+		if terragruntOptions.FromPlansDir != "" {
+			extraArgs = removeVariableArgs(extraArgs)
+		}
+		for _, arg := range extraArgs {
+			if strings.HasPrefix(arg, "-var-file=") {
+				result.VarFiles = append(result.VarFiles, strings.TrimPrefix(arg, "-var-file="))
+			}
+		}
+		result.ExtraArguments = extraArgs
+		terragruntOptions.TerraformCliArgs = insertExtraArgs(terragruntOptions.TerraformCliArgs, extraArgs)
+	}
+
+	if !conf.RunConditions.ShouldRun() {
+		result.Skipped = "run_conditions are not met"
+		return result, nil
+	}
+
+	for _, importFiles := range conf.ImportFiles.Enabled() {
+		result.ImportFiles = append(result.ImportFiles, explainedImportFiles{importFiles.Name, importFiles.Source, importFiles.Files, importFiles.Target})
+	}
+
+	hooks := map[string]config.HookList{
+		"before_imports":    conf.PreHooks.Filter(config.BeforeImports),
+		"before_init_state": conf.PreHooks.Filter(config.BeforeInitState),
+		"after_init_state":  conf.PreHooks.Filter(config.AfterInitState),
+		"post":              conf.PostHooks,
+	}
+	for phase, list := range hooks {
+		for _, hook := range list.ForCommand(command) {
+			if result.Hooks == nil {
+				result.Hooks = map[string][]explainedHook{}
+			}
+			result.Hooks[phase] = append(result.Hooks[phase], explainedHook{hook.Name, strings.TrimSpace(hook.Command), hook.Arguments, hook.IgnoreError, hook.RunOnErrors})
+		}
+	}
+
+	result.AssumeRole = conf.AssumeRole
+
+	result.InitArgs = []string{terragruntOptions.TerraformPath, "init", "-reconfigure"}
+	if conf.RemoteState != nil {
+		result.InitArgs = append(result.InitArgs, conf.RemoteState.ToTerraformInitArgs()...)
+	}
+	if terragruntOptions.PluginsDirectory != "" {
+		result.InitArgs = append(result.InitArgs, fmt.Sprintf("-plugin-dir=%s", terragruntOptions.PluginsDirectory))
+	}
+
+	isApply := actualCommand.Command == "apply" || (actualCommand.Extra != nil && actualCommand.Extra.ActAs == "apply")
+	if terragruntOptions.NonInteractive && isApply && !util.ListContainsElement(terragruntOptions.TerraformCliArgs, "-auto-approve") {
+		terragruntOptions.TerraformCliArgs = append(terragruntOptions.TerraformCliArgs, "-auto-approve")
+	}
+	approvalCommand := actualCommand.Command
+	if actualCommand.Extra != nil {
+		result.CommandArgs = append([]string{actualCommand.Command}, actualCommand.Extra.Arguments...)
+		result.CommandArgs = append(result.CommandArgs, terragruntOptions.TerraformCliArgs[1:]...)
+		approvalCommand = actualCommand.Extra.ActAs
+	} else if command != cmdInit {
+		// Running init manually is not necessary, only the automatic init is executed
+		terragruntOptions.TerraformCliArgs[0] = actualCommand.Command
+		result.CommandArgs = append([]string{terragruntOptions.TerraformPath}, terragruntOptions.TerraformCliArgs...)
+	}
+
+	if approvalConfig := commandApproval(conf, approvalCommand, terragruntOptions); approvalConfig != nil {
+		result.Approval = &explainedApproval{approvalConfig.Name, approvalConfig.ExpectStatements, approvalConfig.CompletedStatements}
+	}
+	return result, nil
+}
+
+// Print the explanations in the format selected by --terragrunt-explain-format
+func printExplanations(terragruntOptions *options.TerragruntOptions, explanations []*moduleExplanation) error {
+	if terragruntOptions.ExplainFormat == explainFormatJSON {
+		result, err := json.MarshalIndent(explanations, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = terragruntOptions.Println(string(result))
+		return err
+	}
+
+	for _, explanation := range explanations {
+		terragruntOptions.Println(explanation)
+	}
+	return nil
+}
+
+func (explanation moduleExplanation) String() string {
+	lines := []string{
+		explainSeparator,
+		fmt.Sprintf("Module:          %s", util.GetPathRelativeToWorkingDir(explanation.Module)),
+		fmt.Sprintf("Config file:     %s", util.GetPathRelativeToWorkingDir(explanation.ConfigFile)),
+		fmt.Sprintf("Command:         %s", explanation.Command),
+	}
+	if explanation.Skipped != "" {
+		return strings.Join(append(lines, fmt.Sprintf("Skipped:         %s", explanation.Skipped)), "\n")
+	}
+	add := func(title, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%-17s%s", title+":", value))
+		}
+	}
+	addList := func(title string, values []string) {
+		if len(values) > 0 {
+			lines = append(lines, title+":")
+			for _, value := range values {
+				lines = append(lines, "  "+value)
+			}
+		}
+	}
+
+	add("Source folder", explanation.SourceFolder)
+	add("Temp folder", explanation.TempFolder)
+	addList("Extra arguments", explanation.ExtraArguments)
+	addList("Var files", explanation.VarFiles)
+	importFiles := make([]string, len(explanation.ImportFiles))
+	for i, item := range explanation.ImportFiles {
+		importFiles[i] = fmt.Sprintf("%s: %s", item.Name, strings.Join(item.Files, ", "))
+		if item.Source != "" {
+			importFiles[i] += " from " + item.Source
+		}
+	}
+	addList("Import files", importFiles)
+	for _, phase := range explainHookPhases {
+		hooks := make([]string, len(explanation.Hooks[phase]))
+		for i, hook := range explanation.Hooks[phase] {
+			hooks[i] = fmt.Sprintf("%s: %s", hook.Name, strings.TrimSpace(hook.Command+" "+strings.Join(hook.Arguments, " ")))
+		}
+		addList(fmt.Sprintf("Hooks (%s)", strings.Replace(phase, "_", " ", -1)), hooks)
+	}
+	if explanation.Approval != nil {
+		add("Approval", fmt.Sprintf("%s (expect %s)", explanation.Approval.Name, strings.Join(explanation.Approval.ExpectStatements, ", ")))
+	}
+	add("Assume role", strings.Join(explanation.AssumeRole, ", "))
+	add("Init", strings.Join(explanation.InitArgs, " "))
+	add("Run", strings.Join(explanation.CommandArgs, " "))
+	return strings.Join(lines, "\n")
+}
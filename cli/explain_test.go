@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertExtraArgs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		args      []string
+		extraArgs []string
+		expected  []string
+	}{
+		{[]string{"plan"}, []string{"-lock=false"}, []string{"plan", "-lock=false"}},
+		{[]string{"plan", "-out=plan"}, []string{"-lock=false"}, []string{"plan", "-lock=false", "-out=plan"}},
+		{[]string{"state", "rm", "aws_s3_bucket.bucket"}, []string{"-lock=false"}, []string{"state", "rm", "-lock=false", "aws_s3_bucket.bucket"}},
+		{[]string{"state"}, []string{"-lock=false"}, []string{"state", "-lock=false"}},
+		{nil, []string{"-lock=false"}, []string{"-lock=false"}},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.expected, insertExtraArgs(tt.args, tt.extraArgs), "%v", tt.args)
+	}
+}
+
+func TestRemoveVariableArgs(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"-lock-timeout=20m", "-var-file=common.tfvars", "-var", "region=us-east-1", "-var=count=2", "-var-file", "app.tfvars", "-parallelism=5"}
+	assert.Equal(t, []string{"-lock-timeout=20m", "-parallelism=5"}, removeVariableArgs(args))
+	assert.Empty(t, removeVariableArgs(nil))
+}
+
+func TestExplainModule(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "explain")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "common.tfvars"), []byte("value = 1"), 0644))
+	configPath := filepath.Join(folder, "terragrunt.hcl")
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(`
+		terraform {
+			extra_arguments "vars" {
+				commands           = ["apply"]
+				arguments          = ["-lock-timeout=20m"]
+				optional_var_files = ["${get_leaf_dir()}/common.tfvars", "${get_leaf_dir()}/missing.tfvars"]
+			}
+		}
+		assume_role = ["arn:aws:iam::123456789012:role/deploy"]
+		pre_hook "plan_only" {
+			command     = "echo"
+			on_commands = ["plan"]
+		}
+		pre_hook "first" {
+			command        = "echo"
+			arguments      = ["first"]
+			before_imports = true
+		}
+		post_hook "notify" {
+			command       = "echo"
+			run_on_errors = true
+		}
+		approval_config "approve" {
+			commands             = ["apply"]
+			expect_statements    = ["Enter a value:"]
+			completed_statements = ["Apply complete!"]
+		}
+	`), 0644))
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+	terragruntOptions.TerraformCliArgs = []string{"apply", "-parallelism=5"}
+	terragruntOptions.NonInteractive = true
+
+	explanation, err := explainModule(terragruntOptions)
+	assert.NoError(t, err)
+	assert.Empty(t, explanation.Skipped)
+	assert.Equal(t, []string{filepath.Join(folder, "common.tfvars")}, explanation.VarFiles)
+	assert.Equal(t, []explainedHook{{Name: "first", Command: "echo", Arguments: []string{"first"}}}, explanation.Hooks["before_imports"])
+	assert.Empty(t, explanation.Hooks["before_init_state"], "The hook is only executed on plan")
+	assert.Equal(t, []explainedHook{{Name: "notify", Command: "echo", RunOnErrors: true}}, explanation.Hooks["post"])
+	assert.Equal(t, &explainedApproval{"approve", []string{"Enter a value:"}, []string{"Apply complete!"}}, explanation.Approval)
+	assert.Equal(t, []string{"arn:aws:iam::123456789012:role/deploy"}, explanation.AssumeRole)
+	assert.Equal(t, []string{"terraform", "apply", "-lock-timeout=20m", "-var-file=" + filepath.Join(folder, "common.tfvars"), "-parallelism=5", "-auto-approve"}, explanation.CommandArgs)
+	assert.Equal(t, []string{"apply", "-parallelism=5"}, terragruntOptions.TerraformCliArgs, "The original arguments should not be modified")
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -24,7 +25,9 @@ func getStack(terragruntOptions *options.TerragruntOptions) (err error) {
 	)
 
 	run := app.Flag("run", "Run the full stack to get the result instead of just analysing the dependencies").Short('r').Bool()
-	output := app.Flag("output", "Specify format of the output (hcl, json, yaml)").Short('o').Enum("h", "hcl", "H", "HCL", "j", "json", "J", "JSON", "y", "yml", "yaml", "Y", "YML", "YAML")
+	output := app.Flag("output", "Specify format of the output (hcl, json, yaml, dot, mermaid)").Short('o').Enum("h", "hcl", "H", "HCL", "j", "json", "J", "JSON", "y", "yml", "yaml", "Y", "YML", "YAML", "dot", "DOT", "mermaid", "MERMAID")
+	layers := app.Flag("layers", "Group the modules into the layers that would be executed in parallel").Short('l').Bool()
+	reverse := app.Flag("reverse", "Use the reverse dependency order to compute the layers (as destroy-all does)").Short('R').Bool()
 	app.Flag("absolute", "Output absolute path (--abs)").Short('a').BoolVar(&absolute)
 	app.Flag("abs", "").Hidden().BoolVar(&absolute)
 	app.HelpFlag.Short('h')
@@ -42,6 +45,9 @@ func getStack(terragruntOptions *options.TerragruntOptions) (err error) {
 			return err
 		}
 
+		for _, inferred := range stack.InferredDependencies() {
+			terragruntOptions.Logger.Warningf("%v, add it to dependencies or use --%s", inferred, optInferDependencies)
+		}
 		stack.SortModules()
 		modules = stack.SimpleModules()
 	}
@@ -50,30 +56,82 @@ func getStack(terragruntOptions *options.TerragruntOptions) (err error) {
 		modules = modules.MakeRelative()
 	}
 
-	switch {
-	case *output != "":
-		var err error
-		var result []byte
-		switch strings.ToLower(*output) {
-		case "h", "hcl":
-			result, err = hcl.MarshalIndent(modules, "", "  ")
-		case "j", "json":
-			result, err = json.MarshalIndent(modules, "", "  ")
-		case "y", "yml", "yaml":
-			result, err = yaml.Marshal(modules)
+	format := strings.ToLower(*output)
+	if *layers {
+		if format == "dot" || format == "mermaid" {
+			return fmt.Errorf("the layers cannot be rendered as %s", format)
 		}
-		if err != nil {
-			panic(err)
+		order := configstack.NormalOrder
+		if *reverse {
+			order = configstack.ReverseOrder
 		}
-		terragruntOptions.Println(string(result))
-	default:
+		return printStackLayers(terragruntOptions, modules.Layers(order), format)
+	}
+
+	switch format {
+	case "":
 		for _, module := range modules {
 			terragruntOptions.Println(module.Path)
 		}
+	case "dot":
+		terragruntOptions.Println(modules.DOT())
+	case "mermaid":
+		terragruntOptions.Println(modules.Mermaid())
+	default:
+		terragruntOptions.Println(marshalStackInfo(modules, format))
+	}
+	return nil
+}
+
+// StackLayer represents a group of modules that could be executed in parallel
+type StackLayer struct {
+	Layer   int      `json:"layer"`
+	Modules []string `json:"modules"`
+}
+
+func printStackLayers(terragruntOptions *options.TerragruntOptions, layers [][]string, format string) error {
+	stackLayers := make([]StackLayer, len(layers))
+	maxParallelism, count := 0, 0
+	for i := range layers {
+		stackLayers[i] = StackLayer{i + 1, layers[i]}
+		if len(layers[i]) > maxParallelism {
+			maxParallelism = len(layers[i])
+		}
+		count += len(layers[i])
+	}
+
+	if format != "" {
+		terragruntOptions.Println(marshalStackInfo(stackLayers, format))
+		return nil
 	}
+
+	for _, layer := range stackLayers {
+		terragruntOptions.Printf("Layer %d:\n", layer.Layer)
+		for _, module := range layer.Modules {
+			terragruntOptions.Printf("  %s\n", module)
+		}
+	}
+	terragruntOptions.Printf("%d module(s) in %d layer(s), up to %d module(s) in parallel (limited to %d workers)\n", count, len(layers), maxParallelism, terragruntOptions.NbWorkers)
 	return nil
 }
 
+func marshalStackInfo(value interface{}, format string) string {
+	var err error
+	var result []byte
+	switch format {
+	case "h", "hcl":
+		result, err = hcl.MarshalIndent(value, "", "  ")
+	case "j", "json":
+		result, err = json.MarshalIndent(value, "", "  ")
+	case "y", "yml", "yaml":
+		result, err = yaml.Marshal(value)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return string(result)
+}
+
 // Get a list of terraform modules sorted by dependency order (but through real execution of the stack modules)
 // Should give the same result as getStack
 func getStackThroughExecution(terragruntOptions *options.TerragruntOptions) (modules configstack.SimpleTerraformModules, err error) {
@@ -6,8 +6,10 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	gotemplateHcl "github.com/coveooss/gotemplate/v3/hcl"
@@ -36,10 +38,14 @@ const (
 
 // TerragruntConfig represents a parsed and expanded configuration
 type TerragruntConfig struct {
+	AfterAllHooks           HookList                    `hcl:"after_all_hook,block" export:"true"`
 	ApprovalConfig          ApprovalConfigList          `hcl:"approval_config,block" export:"true"`
 	AssumeRole              []string                    `export:"true"`
 	AssumeRoleDurationHours *int                        `hcl:"assume_role_duration_hours,attr" export:"true"`
+	BeforeAllHooks          HookList                    `hcl:"before_all_hook,block" export:"true"`
+	ConcurrencyGroups       []ConcurrencyGroup          `hcl:"concurrency_group,block" export:"true"`
 	Dependencies            *ModuleDependencies         `hcl:"dependencies,block" export:"true"`
+	DependencyBlocks        []DependencyConfig          `hcl:"dependency,block" export:"true"`
 	Description             string                      `hcl:"description,optional" export:"true"`
 	ExportVariablesConfigs  []ExportVariablesConfig     `hcl:"export_variables,block" export:"true"`
 	ExportConfigConfigs     []ExportVariablesConfig     `hcl:"export_config,block" export:"true"`
@@ -50,16 +56,21 @@ type TerragruntConfig struct {
 	Inputs                  map[string]interface{}
 	PreHooks                HookList      `hcl:"pre_hook,block" export:"true"`
 	PostHooks               HookList      `hcl:"post_hook,block" export:"true"`
+	PreventDestroy          *bool         `hcl:"prevent_destroy,attr" export:"true"`
 	RemoteState             *remote.State `hcl:"remote_state,block" export:"true"`
+	Retry                   *RetryConfig  `hcl:"retry,block" export:"true"`
 	RunConditions           RunConditions
 	Terraform               *TerraformConfig `hcl:"terraform,block" export:"true"`
+	Timeout                 *string          `hcl:"timeout,attr" export:"true"`
 	UniquenessCriteria      *string          `hcl:"uniqueness_criteria,attr" export:"true"`
 
 	AssumeRoleHclDefinition    cty.Value                    `hcl:"assume_role,optional"`
 	InputsHclDefinition        cty.Value                    `hcl:"inputs,optional"`
 	RunConditionsHclDefinition []runConditionsHclDefinition `hcl:"run_conditions,block"`
 
-	options *options.TerragruntOptions
+	options           *options.TerragruntOptions
+	sourceFiles       map[string]string
+	dependencySources map[string]string
 }
 
 func (conf TerragruntConfig) String() string {
@@ -93,6 +104,87 @@ func (conf TerragruntConfig) AsDictionary() (result collections.IDictionary, err
 	return
 }
 
+// IsDestroyPrevented returns true if the module is protected against destroy (prevent_destroy = true)
+func (conf TerragruntConfig) IsDestroyPrevented() bool {
+	return conf.PreventDestroy != nil && *conf.PreventDestroy
+}
+
+// SourceFiles returns the hash of the content of the files read to build the configuration (the config file itself, the
+// included files and the boot configurations) by file path
+func (conf TerragruntConfig) SourceFiles() map[string]string {
+	return conf.sourceFiles
+}
+
+// DependencySources returns the config file (the config itself or an included file) that declares each dependency by
+// dependency path
+func (conf TerragruntConfig) DependencySources() map[string]string {
+	return conf.dependencySources
+}
+
+// SetDependencySources restores the config files that declare the dependencies (i.e. from a cache)
+func (conf *TerragruntConfig) SetDependencySources(sources map[string]string) {
+	conf.dependencySources = sources
+}
+
+// VarFiles returns the files matching the required and optional var files of the extra_arguments and import_variables
+// blocks (whatever the command is). The files are searched in the module folder, in the terraform source folder and
+// in the local folders specified as source of the blocks (remote sources are identified by their URL in the config).
+func (conf TerragruntConfig) VarFiles() []string {
+	if conf.options == nil {
+		return nil
+	}
+	workingDir := conf.options.WorkingDir
+	localFolders := func(sources ...string) []string {
+		folders := []string{workingDir}
+		for _, source := range sources {
+			if source == "" {
+				continue
+			}
+			if !filepath.IsAbs(source) {
+				source = filepath.Join(workingDir, source)
+			}
+			if stat, err := os.Stat(source); err == nil && stat.IsDir() {
+				folders = append(folders, source)
+			}
+		}
+		return folders
+	}
+	var terraformSource string
+	if conf.Terraform != nil {
+		terraformSource = conf.Terraform.Source
+	}
+
+	var result []string
+	for _, item := range conf.ExtraArgs.Enabled() {
+		folders := localFolders(terraformSource, item.Source)
+		for _, pattern := range append(append([]string{}, item.RequiredVarFiles...), item.OptionalVarFiles...) {
+			result = append(result, conf.globFiles(pattern, false, folders...)...)
+		}
+	}
+	for _, item := range conf.ImportVariables.Enabled() {
+		folders := localFolders(item.Sources...)
+		for _, pattern := range append(append([]string{}, item.RequiredVarFiles...), item.OptionalVarFiles...) {
+			result = append(result, conf.globFiles(pattern, false, folders...)...)
+		}
+	}
+	result = util.RemoveDuplicatesFromListKeepFirst(result)
+	sort.Strings(result)
+	return result
+}
+
+// GetTimeout returns the maximum duration allowed to run the commands of the module (defaultTimeout if no timeout is
+// defined in the configuration, 0 means no timeout)
+func (conf TerragruntConfig) GetTimeout(defaultTimeout time.Duration) (time.Duration, error) {
+	if conf.Timeout == nil || *conf.Timeout == "" {
+		return defaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(*conf.Timeout)
+	if err != nil || timeout < 0 {
+		return 0, tgerrors.WithStackTrace(invalidTimeout(*conf.Timeout))
+	}
+	return timeout, nil
+}
+
 func (conf TerragruntConfig) globFiles(pattern string, stopOnMatch bool, folders ...string) (result []string) {
 	if filepath.IsAbs(pattern) {
 		return utils.GlobFuncTrim(pattern)
@@ -139,6 +231,30 @@ func (tcf *TerragruntConfigFile) convertToTerragruntConfig(terragruntOptions *op
 		}
 	}
 
+	if _, err = tcf.GetTimeout(0); err != nil {
+		return
+	}
+
+	if _, err = tcf.Retry.Policy(); err != nil {
+		return
+	}
+
+	for _, group := range tcf.ConcurrencyGroups {
+		if group.Name == "" || group.Max <= 0 {
+			return nil, tgerrors.WithStackTrace(invalidConcurrencyGroup(group))
+		}
+	}
+
+	// The modules referenced by the dependency blocks must be applied before the current module
+	for _, dependency := range tcf.DependencyBlocks {
+		if tcf.Dependencies == nil {
+			tcf.Dependencies = &ModuleDependencies{}
+		}
+		if folder := dependency.folder(filepath.Dir(tcf.Path)); !util.ListContainsElement(tcf.Dependencies.Paths, folder) {
+			tcf.Dependencies.Paths = append(tcf.Dependencies.Paths, folder)
+		}
+	}
+
 	// Make the context available to sub-objects
 	tcf.options = terragruntOptions
 
@@ -148,6 +264,15 @@ func (tcf *TerragruntConfigFile) convertToTerragruntConfig(terragruntOptions *op
 	}
 
 	if !tcf.InputsHclDefinition.IsNull() {
+		if !tcf.InputsHclDefinition.IsWhollyKnown() {
+			// The outputs of the dependencies are unknown if they have not been fetched (i.e. while resolving a stack)
+			tcf.InputsHclDefinition, _ = cty.Transform(tcf.InputsHclDefinition, func(path cty.Path, value cty.Value) (cty.Value, error) {
+				if !value.IsKnown() {
+					return cty.NullVal(value.Type()), nil
+				}
+				return value, nil
+			})
+		}
 		if err := util.FromCtyValue(tcf.InputsHclDefinition, &tcf.Inputs); err != nil {
 			return nil, err
 		}
@@ -160,6 +285,8 @@ func (tcf *TerragruntConfigFile) convertToTerragruntConfig(terragruntOptions *op
 	tcf.ApprovalConfig.init(tcf)
 	tcf.PreHooks.init(tcf)
 	tcf.PostHooks.init(tcf)
+	tcf.BeforeAllHooks.init(tcf)
+	tcf.AfterAllHooks.init(tcf)
 	tcf.RunConditions = RunConditions{}
 	for _, condition := range tcf.RunConditionsHclDefinition {
 		if !condition.RunIf.IsNull() {
@@ -236,6 +363,16 @@ func (deps *ModuleDependencies) String() string {
 	return fmt.Sprintf("ModuleDependencies{Paths = %v}", deps.Paths)
 }
 
+// ConcurrencyGroup limits the number of modules sharing the same resource (i.e. an AWS account) that can run concurrently
+type ConcurrencyGroup struct {
+	Name string `hcl:"name"`
+	Max  int    `hcl:"max"`
+}
+
+func (group ConcurrencyGroup) String() string {
+	return fmt.Sprintf("ConcurrencyGroup{Name = %s, Max = %d}", group.Name, group.Max)
+}
+
 // TerraformConfig specifies where to find the Terraform configuration files
 type TerraformConfig struct {
 	LegacyExtraArgs TerraformExtraArgumentsList `hcl:"extra_arguments,block"` // Kept here only for retro compatibility
@@ -314,40 +451,18 @@ func ParseConfigFile(terragruntOptions *options.TerragruntOptions, include Inclu
 	if err != nil {
 		return "", nil, err
 	}
+	if config.sourceFiles == nil {
+		config.sourceFiles = map[string]string{}
+	}
+	if absolutePath, err := filepath.Abs(include.Path); err == nil {
+		config.sourceFiles[absolutePath] = util.EncodeBase64Sha1(configString)
+	}
 
 	terragruntOptions.Logger.Tracef("Read configuration file at %s\n%s", include.Path, configString)
 	if terragruntOptions.ApplyTemplate {
-		collections.SetListHelper(gotemplateHcl.GenericListHelper)
-		collections.SetDictionaryHelper(gotemplateHcl.DictionaryHelper)
-
-		var t *template.Template
-		options := template.DefaultOptions()
-		if t, err = template.NewTemplate(terragruntOptions.WorkingDir, terragruntOptions.GetContext(), "", options); err != nil {
-			terragruntOptions.Logger.Debugf("Error creating template for %s: %v", terragruntOptions.WorkingDir, err)
+		if configString, err = applyTemplate(configString, source, include, terragruntOptions); err != nil {
 			return
 		}
-
-		// Add interpolation functions directly to gotemplate
-		// We must create a new context to ensure that the functions are added to the right template since they are
-		// folder dependant
-		includeContext := &resolveContext{
-			include: include,
-			options: terragruntOptions,
-		}
-		t.GetNewContext(filepath.Dir(source), true).AddFunctions(includeContext.getHelperFunctionsInterfaces(), "Terragrunt", nil)
-
-		oldConfigString := configString
-		if configString, err = t.ProcessContent(configString, source); err != nil {
-			terragruntOptions.Logger.Debugf("Error running gotemplate on %s: %v", include.Path, err)
-			return
-		}
-
-		if oldConfigString != configString {
-			terragruntOptions.Logger.Debugf("Configuration file at %s was modified by gotemplate", include.Path)
-			terragruntOptions.Logger.Tracef("Result:\n%s", configString)
-		} else {
-			terragruntOptions.Logger.Tracef("Configuration file at %s was not modified by gotemplate", include.Path)
-		}
 	}
 
 	var userConfig *TerragruntConfig
@@ -364,10 +479,22 @@ func ParseConfigFile(terragruntOptions *options.TerragruntOptions, include Inclu
 				userConfig.Dependencies.Paths[i] = dep
 			}
 		}
+
+		// The dependencies of the included files are already associated to the file that declares them
+		absoluteSource, _ := filepath.Abs(source)
+		for _, dep := range userConfig.Dependencies.Paths {
+			if canonicalPath, err := util.CanonicalPath(dep, ""); err == nil && userConfig.dependencySources[canonicalPath] == "" {
+				if userConfig.dependencySources == nil {
+					userConfig.dependencySources = map[string]string{}
+				}
+				userConfig.dependencySources[canonicalPath] = absoluteSource
+			}
+		}
 	}
 	config.mergeIncludedConfig(*userConfig, terragruntOptions)
 
-	if include.isIncludedBy == nil {
+	// The configurations using outputs of dependencies are not cached if the outputs have not been fetched
+	if include.isIncludedBy == nil && !(terragruntOptions.SkipDependencyOutputs && len(config.DependencyBlocks) > 0) {
 		configFiles.Store(include.Path, []interface{}{configString, config})
 	}
 
@@ -375,7 +502,50 @@ func ParseConfigFile(terragruntOptions *options.TerragruntOptions, include Inclu
 }
 
 var configFiles sync.Map
-var hookWarningGiven bool
+var hookWarning sync.Once
+
+// gotemplate relies on process wide state (collections helpers and lazy initializations) that is not safe for concurrent
+// use, so the configuration files are processed by gotemplate one at a time even if the modules are resolved in parallel
+var templateMutex sync.Mutex
+
+// Process the content of the configuration file with gotemplate
+func applyTemplate(configString, source string, include IncludeConfig, terragruntOptions *options.TerragruntOptions) (string, error) {
+	templateMutex.Lock()
+	defer templateMutex.Unlock()
+
+	collections.SetListHelper(gotemplateHcl.GenericListHelper)
+	collections.SetDictionaryHelper(gotemplateHcl.DictionaryHelper)
+
+	options := template.DefaultOptions()
+	t, err := template.NewTemplate(terragruntOptions.WorkingDir, terragruntOptions.GetContext(), "", options)
+	if err != nil {
+		terragruntOptions.Logger.Debugf("Error creating template for %s: %v", terragruntOptions.WorkingDir, err)
+		return configString, err
+	}
+
+	// Add interpolation functions directly to gotemplate
+	// We must create a new context to ensure that the functions are added to the right template since they are
+	// folder dependant
+	includeContext := &resolveContext{
+		include: include,
+		options: terragruntOptions,
+	}
+	t.GetNewContext(filepath.Dir(source), true).AddFunctions(includeContext.getHelperFunctionsInterfaces(), "Terragrunt", nil)
+
+	result, err := t.ProcessContent(configString, source)
+	if err != nil {
+		terragruntOptions.Logger.Debugf("Error running gotemplate on %s: %v", include.Path, err)
+		return configString, err
+	}
+
+	if result != configString {
+		terragruntOptions.Logger.Debugf("Configuration file at %s was modified by gotemplate", include.Path)
+		terragruntOptions.Logger.Tracef("Result:\n%s", result)
+	} else {
+		terragruntOptions.Logger.Tracef("Configuration file at %s was not modified by gotemplate", include.Path)
+	}
+	return result, nil
+}
 
 // Parse the Terragrunt config contained in the given string.
 func parseConfigString(configString string, terragruntOptions *options.TerragruntOptions, include IncludeConfig) (config *TerragruntConfig, err error) {
@@ -388,10 +558,11 @@ func parseConfigString(configString string, terragruntOptions *options.Terragrun
 	// pre_hooks & post_hooks have been renamed to pre_hook & post_hook, we support old naming to avoid breaking change
 	configString = strings.Replace(configString, "pre_hooks", "pre_hook", -1)
 	configString = strings.Replace(configString, "post_hooks", "post_hook", -1)
-	if !hookWarningGiven && before != configString {
+	if before != configString {
 		// We should issue this warning only once
-		hookWarningGiven = true
-		terragruntOptions.Logger.Warning("pre_hooks/post_hooks are deprecated, please use pre_hook/post_hook instead")
+		hookWarning.Do(func() {
+			terragruntOptions.Logger.Warning("pre_hooks/post_hooks are deprecated, please use pre_hook/post_hook instead")
+		})
 	}
 
 	includeContext := &resolveContext{
@@ -497,6 +668,9 @@ func parseHcl(content string, filename string, out interface{}, resolveContext *
 		return parseDiagnostics
 	}
 
+	if err := resolveContext.loadDependencies(file.Body); err != nil {
+		return err
+	}
 	funcs, err := resolveContext.getHelperFunctionsHCLContext()
 	if err != nil {
 		return err
@@ -564,6 +738,22 @@ func (conf *TerragruntConfig) mergeIncludedConfig(includedConfig TerragruntConfi
 		conf.Description += includedConfig.Description
 	}
 
+	for path, hash := range includedConfig.sourceFiles {
+		if conf.sourceFiles == nil {
+			conf.sourceFiles = map[string]string{}
+		}
+		conf.sourceFiles[path] = hash
+	}
+
+	for path, file := range includedConfig.dependencySources {
+		if conf.dependencySources == nil {
+			conf.dependencySources = map[string]string{}
+		}
+		if conf.dependencySources[path] == "" {
+			conf.dependencySources[path] = file
+		}
+	}
+
 	if conf.RemoteState == nil {
 		conf.RemoteState = includedConfig.RemoteState
 	}
@@ -584,10 +774,48 @@ func (conf *TerragruntConfig) mergeIncludedConfig(includedConfig TerragruntConfi
 		conf.Dependencies.Paths = append(conf.Dependencies.Paths, includedConfig.Dependencies.Paths...)
 	}
 
+	for _, dependency := range includedConfig.DependencyBlocks {
+		// The dependencies defined in the current config have precedence over the included ones
+		found := false
+		for _, existing := range conf.DependencyBlocks {
+			if existing.Name == dependency.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			conf.DependencyBlocks = append(conf.DependencyBlocks, dependency)
+		}
+	}
+
 	if conf.UniquenessCriteria == nil {
 		conf.UniquenessCriteria = includedConfig.UniquenessCriteria
 	}
 
+	if conf.Timeout == nil {
+		conf.Timeout = includedConfig.Timeout
+	}
+
+	if conf.PreventDestroy == nil {
+		conf.PreventDestroy = includedConfig.PreventDestroy
+	}
+
+	conf.Retry = conf.Retry.merge(includedConfig.Retry)
+
+	for _, group := range includedConfig.ConcurrencyGroups {
+		// The groups defined in the current config have precedence over the included ones
+		found := false
+		for _, existing := range conf.ConcurrencyGroups {
+			if existing.Name == group.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			conf.ConcurrencyGroups = append(conf.ConcurrencyGroups, group)
+		}
+	}
+
 	if conf.AssumeRole == nil {
 		conf.AssumeRole = includedConfig.AssumeRole
 	}
@@ -621,6 +849,8 @@ func (conf *TerragruntConfig) mergeIncludedConfig(includedConfig TerragruntConfi
 	conf.ApprovalConfig.Merge(includedConfig.ApprovalConfig)
 	conf.PreHooks.MergePrepend(includedConfig.PreHooks)
 	conf.PostHooks.MergeAppend(includedConfig.PostHooks)
+	conf.BeforeAllHooks.merge(includedConfig.BeforeAllHooks, mergeModePrepend, "before_all_hook")
+	conf.AfterAllHooks.merge(includedConfig.AfterAllHooks, mergeModeAppend, "after_all_hook")
 }
 
 // Parse the config of the given include, if one is specified
@@ -642,6 +872,18 @@ func (err includedConfigMissingPath) Error() string {
 	return fmt.Sprintf("the include configuration in %s must specify a 'path' and/or 'source' parameter", string(err))
 }
 
+type invalidTimeout string
+
+func (err invalidTimeout) Error() string {
+	return fmt.Sprintf("invalid timeout '%s', it must be a positive duration with a unit (i.e. 30m, 1h30m)", string(err))
+}
+
+type invalidConcurrencyGroup ConcurrencyGroup
+
+func (err invalidConcurrencyGroup) Error() string {
+	return fmt.Sprintf("invalid concurrency_group '%s', it must have a name and a max greater than 0 (max = %d)", err.Name, err.Max)
+}
+
 type panicWhileParsingConfig struct {
 	ConfigFile     string
 	RecoveredValue interface{}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DependencyConfig defines a module whose outputs are used by the current configuration (available as
+// dependency.<name>.outputs). The module is also added to the dependencies of the current module.
+//
+// The mock outputs are used instead of the real ones if the dependency has no state or no outputs (i.e. it has not
+// been applied yet) and the current command is one of the allowed commands (all commands except apply and destroy if
+// not specified). Any other error while getting the outputs is returned.
+type DependencyConfig struct {
+	Name                       string    `hcl:"name,label"`
+	ConfigPath                 string    `hcl:"config_path"`
+	MockOutputs                cty.Value `hcl:"mock_outputs,optional"`
+	MockOutputsAllowedCommands []string  `hcl:"mock_outputs_allowed_commands,optional"`
+}
+
+func (dependency DependencyConfig) String() string {
+	return fmt.Sprintf("Dependency %s{ConfigPath = %s, MockOutputsAllowedCommands = %v}", dependency.Name, dependency.ConfigPath, dependency.MockOutputsAllowedCommands)
+}
+
+// The commands on which the mock outputs are not allowed unless they are listed in mock_outputs_allowed_commands
+var mockOutputsDisallowedCommands = []string{"apply", "destroy"}
+
+// Returns the mock outputs if they can be used with the command (nil otherwise)
+func (dependency DependencyConfig) mockOutputs(command string) (map[string]interface{}, error) {
+	if dependency.MockOutputs.IsNull() {
+		return nil, nil
+	}
+	if len(dependency.MockOutputsAllowedCommands) == 0 {
+		// The mock outputs must never be applied unless the user explicitly allowed it
+		if util.ListContainsElement(mockOutputsDisallowedCommands, command) {
+			return nil, nil
+		}
+	} else if !util.ListContainsElement(dependency.MockOutputsAllowedCommands, command) {
+		return nil, nil
+	}
+	var outputs map[string]interface{}
+	if err := util.FromCtyValue(dependency.MockOutputs, &outputs); err != nil {
+		return nil, fmt.Errorf("dependency %s: invalid mock_outputs: %v", dependency.Name, err)
+	}
+	return outputs, nil
+}
+
+// Returns the folder of the dependency (the config path could either be the folder or the config file)
+func (dependency DependencyConfig) folder(configFolder string) string {
+	path := dependency.ConfigPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configFolder, path)
+	}
+	if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+		return filepath.Dir(dependency.ConfigPath)
+	}
+	return dependency.ConfigPath
+}
+
+// The dependency blocks are decoded before the rest of the configuration since their outputs must be known to evaluate
+// the other attributes
+var dependencyBlocksSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "dependency", LabelNames: []string{"name"}}},
+}
+
+// Fetch the outputs of the dependency blocks defined in the body and make them available to the HCL and gotemplate
+// contexts. The outputs are not fetched if SkipDependencyOutputs is set (i.e. while resolving the modules of a stack).
+func (ctx *resolveContext) loadDependencies(body hcl.Body) error {
+	content, _, diagnostics := body.PartialContent(dependencyBlocksSchema)
+	if diagnostics.HasErrors() {
+		return diagnostics
+	}
+	if len(content.Blocks) == 0 || ctx.options.SkipDependencyOutputs {
+		return nil
+	}
+
+	evalContext, err := ctx.getHelperFunctionsHCLContext()
+	if err != nil {
+		return err
+	}
+	for _, block := range content.Blocks {
+		dependency := DependencyConfig{Name: block.Labels[0]}
+		if diagnostics := gohcl.DecodeBody(block.Body, evalContext, &dependency); diagnostics.HasErrors() {
+			return diagnostics
+		}
+		outputs, err := ctx.getDependencyOutputs(dependency.Name, dependency.ConfigPath)
+		if err != nil {
+			return err
+		}
+		if len(outputs) == 0 {
+			// The dependency has no state or no outputs, we use the mock outputs if they are allowed for the command
+			command := util.IndexOrDefault(ctx.options.TerraformCliArgs, 0, "")
+			mockOutputs, err := dependency.mockOutputs(command)
+			if err != nil {
+				return err
+			}
+			if mockOutputs != nil {
+				ctx.options.Logger.Warningf("Using the mock outputs of dependency %s for %s since it has no outputs", dependency.Name, command)
+				outputs = mockOutputs
+			}
+		}
+		if ctx.options.DependencyOutputs == nil {
+			ctx.options.DependencyOutputs = map[string]interface{}{}
+		}
+		ctx.options.DependencyOutputs[dependency.Name] = outputs
+	}
+	return nil
+}
+
+// Run terraform output -json in the module of the dependency. The command is run through terragrunt to use the same
+// source and temporary folder as when the dependency is applied, but the hooks of the dependency are not executed.
+func (ctx *resolveContext) getDependencyOutputs(name, configPath string) (map[string]interface{}, error) {
+	path := configPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(ctx.include.Path), path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+	if stat, err := os.Stat(path); err != nil {
+		return nil, tgerrors.WithStackTrace(errDependencyNotFound{name, path})
+	} else if stat.IsDir() {
+		var found bool
+		if path, found = ctx.options.ConfigPath(path); !found {
+			return nil, tgerrors.WithStackTrace(errDependencyNotFound{name, path})
+		}
+	}
+
+	// The dependency chain is used to detect the cycles since the configuration of the dependency is parsed (and its own
+	// dependency outputs are fetched) before running terraform output
+	currentPath, err := filepath.Abs(ctx.options.TerragruntConfigPath)
+	if err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+	chain := append(append([]string{}, ctx.options.DependencyChain...), currentPath)
+	if util.ListContainsElement(chain, path) {
+		return nil, tgerrors.WithStackTrace(errDependencyOutputsCycle(append(chain, path)))
+	}
+
+	dependencyOptions := ctx.options.Clone(path)
+	dependencyOptions.TerraformCliArgs = []string{"output", "-json"}
+	dependencyOptions.DependencyChain = chain
+	dependencyOptions.PhaseListener = nil
+	// Only the variables specified by the user are relevant for the dependency, not the ones of the current module
+	for key, variable := range dependencyOptions.Variables {
+		if variable.Source < options.VarFileExplicit {
+			delete(dependencyOptions.Variables, key)
+		}
+	}
+	var output, errOutput bytes.Buffer
+	dependencyOptions.Writer = nopWriteCloser{&output}
+	dependencyOptions.ErrWriter = nopWriteCloser{io.MultiWriter(dependencyOptions.ErrWriter, &errOutput)}
+
+	ctx.options.Logger.Debugf("Getting the outputs of dependency %s from %s", name, filepath.Dir(path))
+	if err := dependencyOptions.RunTerragrunt(dependencyOptions); err != nil {
+		if noOutputsRegex.MatchString(output.String() + errOutput.String()) {
+			// Older versions of terraform return an error if there is no state or no outputs
+			return map[string]interface{}{}, nil
+		}
+		return nil, tgerrors.WithStackTrace(errDependencyOutputs{name, path, err})
+	}
+	outputs, err := parseTerraformOutputs(output.String())
+	if err != nil {
+		return nil, tgerrors.WithStackTrace(errDependencyOutputs{name, path, err})
+	}
+	return outputs, nil
+}
+
+// The messages returned by terraform output when the module has no state or no outputs
+var noOutputsRegex = regexp.MustCompile(`(?i)no outputs (defined|found)`)
+
+// Convert the result of terraform output -json (i.e. {"name": {"value": ..., "type": ...}}) into a map of values
+func parseTerraformOutputs(output string) (map[string]interface{}, error) {
+	start := strings.Index(output, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON object found in the output: %s", output)
+	}
+	var outputs map[string]struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&outputs); err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(outputs))
+	for key, output := range outputs {
+		result[key] = output.Value
+	}
+	return result, nil
+}
+
+// Used to capture the output of the dependency without closing the original writers at the end of the command
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+type errDependencyNotFound struct {
+	name string
+	path string
+}
+
+func (err errDependencyNotFound) Error() string {
+	return fmt.Sprintf("dependency %s: %s does not exist or does not contain a terragrunt configuration", err.name, err.path)
+}
+
+type errDependencyOutputs struct {
+	name string
+	path string
+	err  error
+}
+
+func (err errDependencyOutputs) Error() string {
+	return fmt.Sprintf("dependency %s: unable to get the outputs of %s: %v", err.name, filepath.Dir(err.path), err.err)
+}
+
+type errDependencyOutputsCycle []string
+
+func (err errDependencyOutputsCycle) Error() string {
+	folders := make([]string, len(err))
+	for i := range err {
+		folders[i] = util.GetPathRelativeToWorkingDir(filepath.Dir(err[i]))
+	}
+	return fmt.Sprintf("dependency cycle found while fetching the dependency outputs: %s", strings.Join(folders, " -> "))
+}
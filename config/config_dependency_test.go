@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTerragruntConfigDependency(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "dependency")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, os.MkdirAll(filepath.Join(folder, "vpc"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "vpc", DefaultConfigName), []byte(""), 0644))
+	configPath := filepath.Join(folder, "app", DefaultConfigName)
+
+	config := `
+		dependency "vpc" {
+			config_path = "../vpc"
+		}
+		inputs = {
+			vpc_id = dependency.vpc.outputs.vpc_id
+			name   = "app-${dependency.vpc.outputs.name}"
+		}
+	`
+
+	var executed []string
+	terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+	terragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+		executed = append(executed, fmt.Sprintf("%s %v", terragruntOptions.TerragruntConfigPath, terragruntOptions.TerraformCliArgs))
+		fmt.Fprint(terragruntOptions.Writer, `{"vpc_id": {"sensitive": false, "type": "string", "value": "vpc-123"}, "name": {"type": "string", "value": "main"}}`)
+		return nil
+	}
+	terragruntConfig, err := parseConfigString(config, terragruntOptions, IncludeConfig{Path: configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{filepath.Join(folder, "vpc", DefaultConfigName) + " [output -json]"}, executed)
+	assert.Equal(t, map[string]interface{}{"vpc_id": "vpc-123", "name": "app-main"}, terragruntConfig.Inputs)
+	assert.Equal(t, []DependencyConfig{{Name: "vpc", ConfigPath: "../vpc"}}, terragruntConfig.DependencyBlocks)
+	assert.Equal(t, []string{"../vpc"}, terragruntConfig.Dependencies.Paths)
+	assert.Equal(t, map[string]interface{}{"vpc_id": "vpc-123", "name": "main"}, terragruntOptions.DependencyOutputs["vpc"])
+
+	// The outputs are not fetched while resolving a stack, only the dependency matters
+	terragruntOptions = options.NewTerragruntOptionsForTest(configPath)
+	terragruntOptions.SkipDependencyOutputs = true
+	terragruntConfig, err = parseConfigString(config, terragruntOptions, IncludeConfig{Path: configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{"vpc_id": nil, "name": nil}, terragruntConfig.Inputs)
+	assert.Equal(t, []string{"../vpc"}, terragruntConfig.Dependencies.Paths)
+
+	_, err = parseConfigString(`dependency "db" { config_path = "../db" }`, options.NewTerragruntOptionsForTest(configPath), IncludeConfig{Path: configPath})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency db: "+filepath.Join(folder, "db")+" does not exist")
+}
+
+func TestParseTerragruntConfigDependencyMockOutputs(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "dependency")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, os.MkdirAll(filepath.Join(folder, "vpc"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "vpc", DefaultConfigName), []byte(""), 0644))
+	configPath := filepath.Join(folder, "app", DefaultConfigName)
+
+	config := `
+		dependency "vpc" {
+			config_path                   = "../vpc"
+			mock_outputs                  = { vpc_id = "mock-vpc" }
+			mock_outputs_allowed_commands = ["plan", "validate"]
+		}
+		inputs = {
+			vpc_id = dependency.vpc.outputs.vpc_id
+		}
+	`
+
+	defaultConfig := `
+		dependency "vpc" {
+			config_path  = "../vpc"
+			mock_outputs = { vpc_id = "mock-vpc" }
+		}
+		inputs = {
+			vpc_id = dependency.vpc.outputs.vpc_id
+		}
+	`
+
+	const noOutputs = "The state file either has no outputs defined, or all the defined outputs are empty."
+	tests := []struct {
+		name    string
+		config  string
+		command string
+		output  string
+		err     error
+		want    interface{}
+		wantErr string
+	}{
+		{"Real outputs", config, "plan", `{"vpc_id": {"value": "vpc-123"}}`, nil, "vpc-123", ""},
+		{"No outputs", config, "plan", `{}`, nil, "mock-vpc", ""},
+		{"No state", config, "validate", noOutputs, fmt.Errorf("exit status 1"), "mock-vpc", ""},
+		{"Other error", config, "plan", "Error: expired token", fmt.Errorf("exit status 1"), nil, "unable to get the outputs"},
+		{"Command not allowed", config, "apply", `{}`, nil, nil, "Unsupported attribute"},
+		{"All commands allowed", defaultConfig, "init", `{}`, nil, "mock-vpc", ""},
+		{"Apply not allowed by default", defaultConfig, "apply", `{}`, nil, nil, "Unsupported attribute"},
+		{"Destroy not allowed by default", defaultConfig, "destroy", `{}`, nil, nil, "Unsupported attribute"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+			terragruntOptions.TerraformCliArgs = []string{tt.command}
+			terragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+				fmt.Fprint(terragruntOptions.Writer, tt.output)
+				return tt.err
+			}
+			terragruntConfig, err := parseConfigString(tt.config, terragruntOptions, IncludeConfig{Path: configPath})
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"vpc_id": tt.want}, terragruntConfig.Inputs)
+		})
+	}
+}
+
+func TestParseTerragruntConfigDependencyCycle(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "dependency-cycle")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	for _, module := range []string{"a", "b"} {
+		other := map[string]string{"a": "b", "b": "a"}[module]
+		assert.NoError(t, os.MkdirAll(filepath.Join(folder, module), 0755))
+		content := fmt.Sprintf(`dependency "%[1]s" { config_path = "../%[1]s" }`, other)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, module, DefaultConfigName), []byte(content), 0644))
+	}
+
+	configPath := filepath.Join(folder, "a", DefaultConfigName)
+	terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+	terragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+		// As runTerragrunt, the config of the dependency is parsed before running terraform output
+		_, _, err := ParseConfigFile(terragruntOptions, IncludeConfig{Path: terragruntOptions.TerragruntConfigPath})
+		if err == nil {
+			fmt.Fprint(terragruntOptions.Writer, "{}")
+		}
+		return err
+	}
+	_, err = parseConfigString(`dependency "b" { config_path = "../b" }`, terragruntOptions, IncludeConfig{Path: configPath})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "dependency cycle found while fetching the dependency outputs")
+		assert.Regexp(t, `a -> \S*b -> \S*a$`, err.Error())
+	}
+}
+
+func TestParseTerraformOutputs(t *testing.T) {
+	t.Parallel()
+
+	outputs, err := parseTerraformOutputs("Some message\n{\n  \"list\": {\"value\": [1, 2]},\n  \"map\": {\"value\": {\"a\": \"b\"}}\n}\n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"list": []interface{}{1.0, 2.0}, "map": map[string]interface{}{"a": "b"}}, outputs)
+
+	outputs, err = parseTerraformOutputs("{}")
+	assert.NoError(t, err)
+	assert.Empty(t, outputs)
+
+	_, err = parseTerraformOutputs("Error: no state")
+	assert.Error(t, err)
+}
+
+func TestParseTerragruntConfigDependencySources(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "dependency-sources")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	folder, _ = filepath.EvalSymlinks(folder)
+	assert.NoError(t, os.MkdirAll(filepath.Join(folder, "app"), 0755))
+	commonPath := filepath.Join(folder, "common.hcl")
+	assert.NoError(t, ioutil.WriteFile(commonPath, []byte(`dependencies { paths = ["vpc"] }`), 0644))
+	configPath := filepath.Join(folder, "app", DefaultConfigName)
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(`
+		include { path = "../common.hcl" }
+		dependencies { paths = ["../db"] }
+	`), 0644))
+
+	terragruntConfig, err := ReadTerragruntConfig(options.NewTerragruntOptionsForTest(configPath))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{
+		filepath.Join(folder, "vpc"): commonPath,
+		filepath.Join(folder, "db"):  configPath,
+	}, terragruntConfig.DependencySources())
+}
@@ -182,7 +182,15 @@ func (ctx *resolveContext) getHelperFunctionsHCLContext() (*hcl.EvalContext, err
 	if err != nil {
 		return nil, err
 	}
-	return &hcl.EvalContext{Functions: functions, Variables: ctyVariables.AsValueMap()}, nil
+	values := ctyVariables.AsValueMap()
+	if ctx.options.SkipDependencyOutputs {
+		// The outputs of the dependencies are not fetched, so any reference to them is unknown
+		if values == nil {
+			values = map[string]cty.Value{}
+		}
+		values["dependency"] = cty.DynamicVal
+	}
+	return &hcl.EvalContext{Functions: functions, Variables: values}, nil
 }
 
 // Return the directory of the current include file that is processed
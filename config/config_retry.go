@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/shell"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBackoff     = 10 * time.Second
+)
+
+// DefaultRetryableErrors is the list of transient errors that are retried if the retry block does not define retryable_errors
+var DefaultRetryableErrors = []string{
+	`(?i)throttling`,
+	`(?i)rate exceeded`,
+	`ConcurrentModificationException`,
+	`(?i)error (installing|downloading|obtaining) provider`,
+	`(?i)failed to (install|query available) provider`,
+	`(?i)tls handshake timeout`,
+	`(?i)connection reset by peer`,
+	`Error acquiring the state lock`,
+}
+
+// RetryConfig defines how the terraform commands are retried when they fail on transient errors
+type RetryConfig struct {
+	RetryableErrors []string `hcl:"retryable_errors,optional"`
+	MaxAttempts     *int     `hcl:"max_attempts,optional"`
+	Backoff         *string  `hcl:"backoff,optional"`
+}
+
+func (retry *RetryConfig) String() string {
+	return fmt.Sprintf("RetryConfig{RetryableErrors = %v, MaxAttempts = %v, Backoff = %v}", retry.RetryableErrors, retry.MaxAttempts, retry.Backoff)
+}
+
+// Policy converts the retry configuration into the policy used to run the commands (nil if there is no retry configuration)
+func (retry *RetryConfig) Policy() (*shell.RetryPolicy, error) {
+	if retry == nil {
+		return nil, nil
+	}
+
+	policy := &shell.RetryPolicy{MaxAttempts: defaultRetryMaxAttempts, Backoff: defaultRetryBackoff}
+	if retry.MaxAttempts != nil {
+		if *retry.MaxAttempts < 1 {
+			return nil, tgerrors.WithStackTrace(invalidRetryMaxAttempts(*retry.MaxAttempts))
+		}
+		policy.MaxAttempts = *retry.MaxAttempts
+	}
+	if retry.Backoff != nil {
+		backoff, err := time.ParseDuration(*retry.Backoff)
+		if err != nil || backoff < 0 {
+			return nil, tgerrors.WithStackTrace(invalidRetryBackoff(*retry.Backoff))
+		}
+		policy.Backoff = backoff
+	}
+
+	retryableErrors := retry.RetryableErrors
+	if len(retryableErrors) == 0 {
+		retryableErrors = DefaultRetryableErrors
+	}
+	for _, pattern := range retryableErrors {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, tgerrors.WithStackTrace(invalidRetryableError{pattern, err})
+		}
+		policy.RetryableErrors = append(policy.RetryableErrors, expression)
+	}
+	return policy, nil
+}
+
+// Merge the retry configuration of an included file (the values defined in the current configuration have precedence)
+func (retry *RetryConfig) merge(included *RetryConfig) *RetryConfig {
+	if retry == nil {
+		return included
+	}
+	if included == nil {
+		return retry
+	}
+	if retry.MaxAttempts == nil {
+		retry.MaxAttempts = included.MaxAttempts
+	}
+	if retry.Backoff == nil {
+		retry.Backoff = included.Backoff
+	}
+	for _, pattern := range included.RetryableErrors {
+		if !util.ListContainsElement(retry.RetryableErrors, pattern) {
+			retry.RetryableErrors = append(retry.RetryableErrors, pattern)
+		}
+	}
+	return retry
+}
+
+type invalidRetryMaxAttempts int
+
+func (err invalidRetryMaxAttempts) Error() string {
+	return fmt.Sprintf("invalid retry max_attempts %d, it must be greater than 0", int(err))
+}
+
+type invalidRetryBackoff string
+
+func (err invalidRetryBackoff) Error() string {
+	return fmt.Sprintf("invalid retry backoff '%s', it must be a positive duration with a unit (i.e. 5s, 1m)", string(err))
+}
+
+type invalidRetryableError struct {
+	Pattern string
+	Err     error
+}
+
+func (err invalidRetryableError) Error() string {
+	return fmt.Sprintf("invalid retryable error '%s': %v", err.Pattern, err.Err)
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/remote"
@@ -408,6 +411,7 @@ func TestMergeConfigIntoIncludedConfig(t *testing.T) {
 	t.Parallel()
 
 	options := options.NewTerragruntOptionsForTest("TestMergeConfigIntoIncludedConfig")
+	backoff := "1m"
 
 	testCases := []struct {
 		config         TerragruntConfig
@@ -454,6 +458,16 @@ func TestMergeConfigIntoIncludedConfig(t *testing.T) {
 			getExtraArgsConfig(options, argConfig{name: "overrideArgs", extraArgs: []string{"-parent"}}),
 			getExtraArgsConfig(options, argConfig{name: "overrideArgs", extraArgs: []string{"-child"}}),
 		},
+		{
+			TerragruntConfig{ConcurrencyGroups: []ConcurrencyGroup{{"account", 1}}},
+			TerragruntConfig{ConcurrencyGroups: []ConcurrencyGroup{{"account", 5}, {"cluster", 2}}},
+			TerragruntConfig{ConcurrencyGroups: []ConcurrencyGroup{{"account", 1}, {"cluster", 2}}},
+		},
+		{
+			TerragruntConfig{Retry: &RetryConfig{RetryableErrors: []string{"child"}}},
+			TerragruntConfig{Retry: &RetryConfig{RetryableErrors: []string{"parent"}, Backoff: &backoff}},
+			TerragruntConfig{Retry: &RetryConfig{RetryableErrors: []string{"child", "parent"}, Backoff: &backoff}},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -600,6 +614,170 @@ func TestParseTerragruntConfigTerraformWithMultipleExtraArguments(t *testing.T)
 	assert.Equal(t, TerraformCommandWithVarFile, terragruntConfig.ExtraArgs[3].Commands)
 }
 
+func TestParseTerragruntConfigTimeout(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseConfigString(`timeout = "1h30m"`, mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, err := terragruntConfig.GetTimeout(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, timeout)
+
+	terragruntConfig, err = parseConfigString("", mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, err = terragruntConfig.GetTimeout(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, timeout)
+
+	_, err = parseConfigString(`timeout = "forever"`, mockOptions, mockDefaultInclude)
+	assert.EqualError(t, err, "caught error while initializing the Terragrunt config: invalid timeout 'forever', it must be a positive duration with a unit (i.e. 30m, 1h30m)")
+}
+
+func TestParseTerragruntConfigConcurrencyGroups(t *testing.T) {
+	t.Parallel()
+
+	config := `
+		concurrency_group {
+			name = "aws-123456789012"
+			max  = 2
+		}
+		concurrency_group {
+			name = "k8s-prod"
+			max  = 1
+		}
+	`
+	terragruntConfig, err := parseConfigString(config, mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []ConcurrencyGroup{{"aws-123456789012", 2}, {"k8s-prod", 1}}, terragruntConfig.ConcurrencyGroups)
+
+	_, err = parseConfigString(`concurrency_group {
+		name = "aws"
+		max  = 0
+	}`, mockOptions, mockDefaultInclude)
+	assert.EqualError(t, err, "caught error while initializing the Terragrunt config: invalid concurrency_group 'aws', it must have a name and a max greater than 0 (max = 0)")
+}
+
+func TestParseTerragruntConfigRetry(t *testing.T) {
+	t.Parallel()
+
+	config := `
+		retry {
+			retryable_errors = ["(?i)throttling", "ConcurrentModificationException"]
+			max_attempts     = 5
+			backoff          = "30s"
+		}
+	`
+	terragruntConfig, err := parseConfigString(config, mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := terragruntConfig.Retry.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Equal(t, 30*time.Second, policy.Backoff)
+	assert.Len(t, policy.RetryableErrors, 2)
+
+	terragruntConfig, err = parseConfigString("retry {}", mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err = terragruntConfig.Retry.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRetryMaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, defaultRetryBackoff, policy.Backoff)
+	assert.Len(t, policy.RetryableErrors, len(DefaultRetryableErrors))
+
+	terragruntConfig, err = parseConfigString("", mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err = terragruntConfig.Retry.Policy()
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+
+	_, err = parseConfigString(`retry { max_attempts = 0 }`, mockOptions, mockDefaultInclude)
+	assert.EqualError(t, err, "caught error while initializing the Terragrunt config: invalid retry max_attempts 0, it must be greater than 0")
+
+	_, err = parseConfigString(`retry { retryable_errors = ["("] }`, mockOptions, mockDefaultInclude)
+	assert.EqualError(t, err, "caught error while initializing the Terragrunt config: invalid retryable error '(': error parsing regexp: missing closing ): `(`")
+}
+
+func TestParseTerragruntConfigStackHooks(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "stack-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, DefaultConfigName), []byte(`
+		before_all_hook "credentials" {
+			command = "refresh-credentials"
+		}
+		after_all_hook "notify" {
+			command       = "notify"
+			run_on_errors = true
+		}
+	`), 0644))
+	configPath := filepath.Join(folder, "stack", DefaultConfigName)
+
+	config := `
+		include {
+			path = find_in_parent_folders()
+		}
+		before_all_hook "login" {
+			command = "login"
+		}
+		after_all_hook "notify" {
+			command     = "notify-stack"
+			on_commands = ["apply"]
+		}
+	`
+	terragruntConfig, err := parseConfigString(config, options.NewTerragruntOptionsForTest(configPath), IncludeConfig{Path: configPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, terragruntConfig.BeforeAllHooks, 2) {
+		assert.Equal(t, "credentials", terragruntConfig.BeforeAllHooks[0].Name)
+		assert.Equal(t, "login", terragruntConfig.BeforeAllHooks[1].Name)
+	}
+	if assert.Len(t, terragruntConfig.AfterAllHooks, 1) {
+		assert.Equal(t, "notify-stack", terragruntConfig.AfterAllHooks[0].Command)
+		assert.Equal(t, []string{"apply"}, terragruntConfig.AfterAllHooks[0].OnCommands)
+	}
+	assert.Empty(t, terragruntConfig.PreHooks)
+	assert.Empty(t, terragruntConfig.PostHooks)
+}
+
+func TestParseTerragruntConfigPreventDestroy(t *testing.T) {
+	t.Parallel()
+
+	terragruntConfig, err := parseConfigString("prevent_destroy = true", mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, terragruntConfig.IsDestroyPrevented())
+
+	terragruntConfig, err = parseConfigString("", mockOptions, mockDefaultInclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, terragruntConfig.IsDestroyPrevented())
+
+	// The setting of the module has precedence over the one of the included config
+	conf := &TerragruntConfig{PreventDestroy: new(bool)}
+	prevented := true
+	conf.mergeIncludedConfig(TerragruntConfig{PreventDestroy: &prevented}, mockOptions)
+	assert.False(t, conf.IsDestroyPrevented())
+	conf = &TerragruntConfig{}
+	conf.mergeIncludedConfig(TerragruntConfig{PreventDestroy: &prevented}, mockOptions)
+	assert.True(t, conf.IsDestroyPrevented())
+}
+
 func TestFindConfigFilesInPathOneNewConfig(t *testing.T) {
 	t.Parallel()
 
@@ -623,6 +801,19 @@ func TestFindConfigFilesInPathMultipleConfigs(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestFindConfigFilesInPathWithIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{
+		"../test/fixture-config-files/ignore-file/apps/web/terragrunt.hcl",
+		"../test/fixture-config-files/ignore-file/keep/terragrunt.hcl",
+	}
+	actual, err := newOptionsWorkingDir("../test/fixture-config-files/ignore-file").FindConfigFilesInPath("")
+
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.Equal(t, expected, actual)
+}
+
 func newOptionsWorkingDir(workingDir string) *options.TerragruntOptions {
 	opts := options.NewTerragruntOptionsForTest(DefaultConfigName)
 	opts.WorkingDir = workingDir
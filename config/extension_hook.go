@@ -148,6 +148,17 @@ func (list HookList) Filter(filter HookFilter) HookList {
 	return result
 }
 
+// ForCommand returns the hooks that would be executed for the command in their execution order
+func (list HookList) ForCommand(command string) HookList {
+	result := make(HookList, 0, len(list))
+	for _, hook := range list.Enabled() {
+		if len(hook.OnCommands) == 0 || util.ListContainsElement(hook.OnCommands, command) {
+			result = append(result, hook)
+		}
+	}
+	return result.sort()
+}
+
 // HookFilter is used to filter the hook on supplied criteria
 type HookFilter func(Hook) bool
 
@@ -167,6 +178,9 @@ func (list HookList) Run(status error, args ...interface{}) (result []interface{
 	}
 
 	list.sort()
+	// The previous phase is restored once the hooks are completed
+	terragruntOptions := list[0].options()
+	defer terragruntOptions.SetPhase(terragruntOptions.SetPhase(options.PhaseHooks))
 
 	var (
 		errs        errorArray
@@ -0,0 +1,191 @@
+package configstack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/remote"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// discoveryCacheEntry contains the part of the configuration of a module that is relevant to build the stack along with
+// the hash of the files read to parse it. The entry is only valid if none of these files has changed and if the options
+// that could affect the parsing (command, environment variables, variables, gotemplate context, boot configurations)
+// are the same.
+type discoveryCacheEntry struct {
+	Settings           string                    `json:"settings"`
+	Files              map[string]string         `json:"files"`
+	Dependencies       []string                  `json:"dependencies,omitempty"`
+	DependencySources  map[string]string         `json:"dependency_sources,omitempty"`
+	TerraformSource    *string                   `json:"terraform_source,omitempty"`
+	UniquenessCriteria *string                   `json:"uniqueness_criteria,omitempty"`
+	RemoteState        *discoveryCacheState      `json:"remote_state,omitempty"`
+	Inputs             map[string]interface{}    `json:"inputs,omitempty"`
+	ConcurrencyGroups  []config.ConcurrencyGroup `json:"concurrency_groups,omitempty"`
+	Timeout            *string                   `json:"timeout,omitempty"`
+	PreventDestroy     *bool                     `json:"prevent_destroy,omitempty"`
+}
+
+type discoveryCacheState struct {
+	Backend string                 `json:"backend"`
+	Config  map[string]interface{} `json:"config,omitempty"`
+}
+
+// The elements of the options that are used while parsing the configuration files
+type discoverySettings struct {
+	ApplyTemplate              bool
+	TemplateAdditionalPatterns []string
+	BootConfigurationPaths     []string
+	PreBootConfigurationPaths  []string
+	Source                     string
+	Command                    string
+	Env                        map[string]string
+	Context                    map[string]interface{}
+	Variables                  map[string]interface{}
+}
+
+// The environment variables that are different on each run or that are updated during the run (they are not available
+// to the configuration files while the stack is resolved)
+var discoveryIgnoredEnv = []string{options.EnvRunID, options.EnvLastError, options.EnvLastStatus, options.EnvError, options.EnvStatus}
+
+// DiscoveryCacheFile returns the file where the discovery result of the module defined by the config file is saved
+func DiscoveryCacheFile(terragruntConfigPath string) string {
+	return util.GetTempDownloadFolder("terragrunt-cache", "discovery", util.EncodeBase64Sha1(terragruntConfigPath)+".json")
+}
+
+// Returns a hash of the options that could change the result of the parsing of the configuration files (false if the
+// options cannot be serialized, in that case, the cache is not used)
+func discoveryCacheSettings(terragruntOptions *options.TerragruntOptions) (string, bool) {
+	settings := discoverySettings{
+		ApplyTemplate:              terragruntOptions.ApplyTemplate,
+		TemplateAdditionalPatterns: terragruntOptions.TemplateAdditionalPatterns,
+		BootConfigurationPaths:     terragruntOptions.BootConfigurationPaths,
+		PreBootConfigurationPaths:  terragruntOptions.PreBootConfigurationPaths,
+		Source:                     terragruntOptions.Source,
+		Command:                    util.IndexOrDefault(terragruntOptions.TerraformCliArgs, 0, ""),
+		Env:                        make(map[string]string, len(terragruntOptions.Env)),
+		Context:                    make(map[string]interface{}, len(terragruntOptions.Context)),
+		Variables:                  make(map[string]interface{}, len(terragruntOptions.Variables)),
+	}
+	for key, value := range terragruntOptions.Context {
+		// The run id is different on each run and should not be used to render the configuration
+		if key != "RunID" {
+			settings.Context[key] = value
+		}
+	}
+	for key, value := range terragruntOptions.Env {
+		if !util.ListContainsElement(discoveryIgnoredEnv, key) {
+			settings.Env[key] = value
+		}
+	}
+	for key, variable := range terragruntOptions.Variables {
+		settings.Variables[key] = variable.Value
+	}
+	content, err := json.Marshal(settings)
+	if err != nil {
+		return "", false
+	}
+	return util.EncodeBase64Sha1(string(content)), true
+}
+
+// Returns the configuration saved by a previous discovery of the module if the files and options used to parse it
+// have not changed (nil otherwise)
+func loadDiscoveryCache(terragruntConfigPath string, terragruntOptions *options.TerragruntOptions) *config.TerragruntConfig {
+	if terragruntOptions.NoDiscoveryCache {
+		return nil
+	}
+	settings, ok := discoveryCacheSettings(terragruntOptions)
+	if !ok {
+		return nil
+	}
+	content, err := ioutil.ReadFile(DiscoveryCacheFile(terragruntConfigPath))
+	if err != nil {
+		return nil
+	}
+	var entry discoveryCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		terragruntOptions.Logger.Debugf("Ignoring the invalid discovery cache of %s: %v", terragruntConfigPath, err)
+		return nil
+	}
+	if entry.Settings != settings || len(entry.Files) == 0 {
+		return nil
+	}
+	for file, hash := range entry.Files {
+		if content, err := ioutil.ReadFile(file); err != nil || util.EncodeBase64Sha1(string(content)) != hash {
+			terragruntOptions.Logger.Debugf("Discovery cache of %s is outdated since %s has changed", terragruntConfigPath, file)
+			return nil
+		}
+	}
+	return entry.config()
+}
+
+// Save the part of the configuration that is relevant to build the stack for the next discoveries
+func saveDiscoveryCache(terragruntConfigPath string, terragruntConfig *config.TerragruntConfig, terragruntOptions *options.TerragruntOptions) {
+	if terragruntOptions.NoDiscoveryCache {
+		return
+	}
+	settings, ok := discoveryCacheSettings(terragruntOptions)
+	if !ok || len(terragruntConfig.SourceFiles()) == 0 {
+		return
+	}
+	entry := newDiscoveryCacheEntry(settings, terragruntConfig)
+	content, err := json.Marshal(entry)
+	if err == nil {
+		file := DiscoveryCacheFile(terragruntConfigPath)
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err == nil {
+			err = ioutil.WriteFile(file, content, 0644)
+		}
+	}
+	if err != nil {
+		terragruntOptions.Logger.Debugf("Unable to save the discovery cache of %s: %v", terragruntConfigPath, err)
+	}
+}
+
+func newDiscoveryCacheEntry(settings string, terragruntConfig *config.TerragruntConfig) discoveryCacheEntry {
+	entry := discoveryCacheEntry{
+		Settings:           settings,
+		Files:              terragruntConfig.SourceFiles(),
+		DependencySources:  terragruntConfig.DependencySources(),
+		UniquenessCriteria: terragruntConfig.UniquenessCriteria,
+		Inputs:             terragruntConfig.Inputs,
+		ConcurrencyGroups:  terragruntConfig.ConcurrencyGroups,
+		Timeout:            terragruntConfig.Timeout,
+		PreventDestroy:     terragruntConfig.PreventDestroy,
+	}
+	if terragruntConfig.Dependencies != nil {
+		entry.Dependencies = append([]string{}, terragruntConfig.Dependencies.Paths...)
+	}
+	if terragruntConfig.Terraform != nil {
+		entry.TerraformSource = &terragruntConfig.Terraform.Source
+	}
+	if terragruntConfig.RemoteState != nil {
+		entry.RemoteState = &discoveryCacheState{terragruntConfig.RemoteState.Backend, terragruntConfig.RemoteState.Config}
+	}
+	return entry
+}
+
+// Rebuild the configuration from the cache entry (only the elements used by the stack are available)
+func (entry discoveryCacheEntry) config() *config.TerragruntConfig {
+	result := &config.TerragruntConfig{
+		UniquenessCriteria: entry.UniquenessCriteria,
+		Inputs:             entry.Inputs,
+		ConcurrencyGroups:  entry.ConcurrencyGroups,
+		Timeout:            entry.Timeout,
+		PreventDestroy:     entry.PreventDestroy,
+	}
+	result.SetDependencySources(entry.DependencySources)
+	if entry.Dependencies != nil {
+		result.Dependencies = &config.ModuleDependencies{Paths: entry.Dependencies}
+	}
+	if entry.TerraformSource != nil {
+		result.Terraform = &config.TerraformConfig{Source: *entry.TerraformSource}
+	}
+	if entry.RemoteState != nil {
+		result.RemoteState = &remote.State{Backend: entry.RemoteState.Backend, Config: entry.RemoteState.Config}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package configstack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoveryCache(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "discovery-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	write := func(name, content string) string {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	write("common.hcl", `dependencies { paths = ["vpc"] }`)
+	write("app/main.tf", "")
+	configPath := write("app/terragrunt.hcl", `
+		include { path = "../common.hcl" }
+		terraform { source = "../modules/app" }
+		timeout = "10m"
+		inputs = { count = 3 }
+	`)
+	defer os.Remove(DiscoveryCacheFile(configPath))
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(configPath)
+	terragruntOptions.NoDiscoveryCache = false
+
+	assert.Nil(t, loadDiscoveryCache(configPath, terragruntOptions), "No cache before the first discovery")
+	module, _, err := resolveTerraformModule(configPath, terragruntOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(folder, "vpc")}, module.Config.Dependencies.Paths)
+
+	cached := loadDiscoveryCache(configPath, terragruntOptions)
+	if assert.NotNil(t, cached, "The discovery result should be cached") {
+		assert.Equal(t, module.Config.Dependencies, cached.Dependencies)
+		assert.Equal(t, module.Config.DependencySources(), cached.DependencySources())
+		assert.Equal(t, "../modules/app", cached.Terraform.Source)
+		assert.Equal(t, "10m", *cached.Timeout)
+		assert.EqualValues(t, 3, cached.Inputs["count"])
+	}
+
+	bypassOptions := terragruntOptions.Clone(configPath)
+	bypassOptions.NoDiscoveryCache = true
+	assert.Nil(t, loadDiscoveryCache(configPath, bypassOptions), "The cache is ignored with --terragrunt-no-discovery-cache")
+
+	otherOptions := terragruntOptions.Clone(configPath)
+	otherOptions.Context = map[string]interface{}{"Command": "destroy"}
+	assert.Nil(t, loadDiscoveryCache(configPath, otherOptions), "The cache depends on the gotemplate context")
+
+	otherOptions = terragruntOptions.Clone(configPath)
+	otherOptions.Env["DEPLOYMENT"] = "production"
+	assert.Nil(t, loadDiscoveryCache(configPath, otherOptions), "The cache depends on the environment variables")
+
+	otherOptions = terragruntOptions.Clone(configPath)
+	otherOptions.Env[options.EnvRunID] = "another-run"
+	assert.NotNil(t, loadDiscoveryCache(configPath, otherOptions), "The cache doesn't depend on the run id")
+
+	otherOptions = terragruntOptions.Clone(configPath)
+	otherOptions.TerraformCliArgs = []string{"destroy"}
+	assert.Nil(t, loadDiscoveryCache(configPath, otherOptions), "The cache depends on the command")
+
+	// Modifying an included file invalidates the cache
+	write("common.hcl", `dependencies { paths = ["vpc", "db"] }`)
+	assert.Nil(t, loadDiscoveryCache(configPath, terragruntOptions))
+}
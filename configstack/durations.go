@@ -0,0 +1,198 @@
+package configstack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Supported scheduling policies
+const (
+	ScheduleCriticalPath = "critical-path"
+	ScheduleFIFO         = "fifo"
+)
+
+// moduleDurations is the history of the durations of the modules (by command and module path) recorded by the
+// previous runs. It is used to estimate the length of the dependency chains of the modules.
+type moduleDurations map[string]map[string]time.Duration
+
+// DurationsFile returns the file where the durations of the modules are saved
+func DurationsFile() string {
+	return util.GetTempDownloadFolder("terragrunt-cache", "durations.json")
+}
+
+// Load the durations recorded by the previous runs (an empty history is returned if the file cannot be read). No history
+// is kept if there is no run id.
+func loadDurations(terragruntOptions *options.TerragruntOptions) moduleDurations {
+	if terragruntOptions.Env[options.EnvRunID] == "" {
+		return nil
+	}
+	durations, err := readDurations(DurationsFile())
+	if err != nil {
+		terragruntOptions.Logger.Debugf("Ignoring the invalid durations history %s: %v", DurationsFile(), err)
+	}
+	return durations
+}
+
+// Returns the durations saved in the file (an empty history is returned if the file doesn't exist or is invalid)
+func readDurations(file string) (moduleDurations, error) {
+	durations := moduleDurations{}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return durations, nil
+		}
+		return durations, err
+	}
+	if err := json.Unmarshal(content, &durations); err != nil {
+		return moduleDurations{}, err
+	}
+	return durations, nil
+}
+
+// Returns the recorded duration of the module for its command (false if there is no history)
+func (durations moduleDurations) get(module *TerraformModule) (time.Duration, bool) {
+	duration, found := durations[moduleCommand(module)][module.Path]
+	return duration, found
+}
+
+// Record the duration of the modules that succeeded during the run. The estimate is the average between the previous
+// estimate and the current duration to smooth the variations.
+func (durations moduleDurations) update(modules map[string]*runningModule) {
+	if durations == nil {
+		return
+	}
+	for _, module := range modules {
+		if module.outcome() != outcomeSucceeded || module.resumed || module.startTime.IsZero() {
+			continue
+		}
+		command := moduleCommand(module.Module)
+		if durations[command] == nil {
+			durations[command] = map[string]time.Duration{}
+		}
+		duration := module.duration()
+		if previous, found := durations[command][module.Module.Path]; found {
+			duration = (previous + duration) / 2
+		}
+		durations[command][module.Module.Path] = duration
+	}
+}
+
+// Save the durations of the modules that succeeded during the run for the next runs (nothing is saved if there is no
+// history)
+func (durations moduleDurations) save(modules map[string]*runningModule) error {
+	if durations == nil {
+		return nil
+	}
+	return saveDurations(DurationsFile(), modules)
+}
+
+// Record the durations of the modules in the file. Since several runs can share the file, the history is reloaded and
+// updated while holding a lock on a sibling lock file to keep the durations saved by the other runs and the file is
+// replaced atomically (the content is written to a temporary file that is renamed) so a run never reads a partially
+// written file.
+func saveDurations(file string, modules map[string]*runningModule) error {
+	unlock, err := util.LockFile(file + ".lock")
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	defer unlock()
+
+	durations, _ := readDurations(file)
+	durations.update(modules)
+	content, err := json.MarshalIndent(durations, "", "  ")
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return tgerrors.WithStackTrace(err)
+	}
+	if err := temp.Close(); err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	return tgerrors.WithStackTrace(os.Rename(temp.Name(), file))
+}
+
+func moduleCommand(module *TerraformModule) string {
+	return util.IndexOrDefault(module.TerragruntOptions.TerraformCliArgs, 0, "")
+}
+
+// Set the priority of each module to the estimated length of the chain of modules that must wait for it (including
+// itself). The modules without history are estimated with the average duration of the modules with history (or 1s if
+// there is no history at all).
+func setCriticalPathPriorities(modules map[string]*runningModule, durations moduleDurations) {
+	var total time.Duration
+	var count int
+	for _, module := range modules {
+		if duration, found := durations.get(module.Module); found {
+			total += duration
+			count++
+		}
+	}
+	defaultEstimate := time.Second
+	if count > 0 {
+		defaultEstimate = total / time.Duration(count)
+	}
+
+	priorities := make(map[*runningModule]time.Duration, len(modules))
+	var priority func(module *runningModule, visiting map[*runningModule]bool) time.Duration
+	priority = func(module *runningModule, visiting map[*runningModule]bool) time.Duration {
+		if result, found := priorities[module]; found {
+			return result
+		}
+		visiting[module] = true
+		var longestChain time.Duration
+		for _, dependent := range module.NotifyWhenDone {
+			if visiting[dependent] {
+				continue
+			}
+			if chain := priority(dependent, visiting); chain > longestChain {
+				longestChain = chain
+			}
+		}
+		delete(visiting, module)
+
+		estimate, found := durations.get(module.Module)
+		if !found {
+			estimate = defaultEstimate
+		}
+		priorities[module] = estimate + longestChain
+		return priorities[module]
+	}
+
+	for _, module := range modules {
+		module.priority = priority(module, map[*runningModule]bool{})
+	}
+}
+
+// Returns the modules sorted by priority (highest first), they are started in that order to give the workers to the
+// modules with the longest chains when there are more ready modules than workers
+func sortByPriority(modules map[string]*runningModule) []*runningModule {
+	result := make([]*runningModule, 0, len(modules))
+	for _, module := range modules {
+		result = append(result, module)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].priority != result[j].priority {
+			return result[i].priority > result[j].priority
+		}
+		return result[i].Module.Path < result[j].Module.Path
+	})
+	return result
+}
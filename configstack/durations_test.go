@@ -0,0 +1,123 @@
+package configstack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// a <- b <- c
+// d
+func createDurationsTestModules(t *testing.T) map[string]*runningModule {
+	var ran bool
+	newModule := func(path string, dependencies ...*TerraformModule) *TerraformModule {
+		module := &TerraformModule{
+			Path:              path,
+			Dependencies:      dependencies,
+			Config:            config.TerragruntConfig{},
+			TerragruntOptions: optionsWithMockTerragruntCommand(path, nil, &ran),
+		}
+		module.TerragruntOptions.TerraformCliArgs = []string{"apply"}
+		return module
+	}
+	moduleA := newModule("a")
+	moduleB := newModule("b", moduleA)
+	moduleC := newModule("c", moduleB)
+	moduleD := newModule("d")
+
+	modules, err := toRunningModules([]*TerraformModule{moduleA, moduleB, moduleC, moduleD}, NormalOrder)
+	assert.NoError(t, err)
+	return modules
+}
+
+func TestSetCriticalPathPriorities(t *testing.T) {
+	t.Parallel()
+
+	modules := createDurationsTestModules(t)
+	setCriticalPathPriorities(modules, nil)
+	assert.Equal(t, 3*time.Second, modules["a"].priority)
+	assert.Equal(t, 2*time.Second, modules["b"].priority)
+	assert.Equal(t, time.Second, modules["c"].priority)
+	assert.Equal(t, time.Second, modules["d"].priority)
+
+	// The modules without history are estimated with the average of the known durations
+	durations := moduleDurations{"apply": {"c": 2 * time.Minute, "d": 10 * time.Minute}}
+	setCriticalPathPriorities(modules, durations)
+	assert.Equal(t, 14*time.Minute, modules["a"].priority)
+	assert.Equal(t, 8*time.Minute, modules["b"].priority)
+	assert.Equal(t, 2*time.Minute, modules["c"].priority)
+	assert.Equal(t, 10*time.Minute, modules["d"].priority)
+
+	var sorted []string
+	for _, module := range sortByPriority(modules) {
+		sorted = append(sorted, module.Module.Path)
+	}
+	assert.Equal(t, []string{"a", "d", "b", "c"}, sorted)
+
+	// The durations of the other commands are ignored
+	setCriticalPathPriorities(modules, moduleDurations{"plan": {"d": time.Hour}})
+	assert.Equal(t, time.Second, modules["d"].priority)
+}
+
+func TestModuleDurationsUpdate(t *testing.T) {
+	t.Parallel()
+
+	modules := createDurationsTestModules(t)
+	now := time.Now()
+	modules["a"].startTime, modules["a"].endTime = now.Add(-4*time.Minute), now
+	modules["b"].startTime, modules["b"].endTime = now.Add(-time.Minute), now
+	modules["c"].startTime, modules["c"].endTime = now.Add(-time.Minute), now
+	modules["c"].Err = assert.AnError
+
+	durations := moduleDurations{"apply": {"a": 2 * time.Minute}}
+	durations.update(modules)
+	assert.Equal(t, moduleDurations{"apply": {"a": 3 * time.Minute, "b": time.Minute}}, durations)
+
+	// There is no history if there is no run id
+	var noHistory moduleDurations
+	noHistory.update(modules)
+	assert.NoError(t, noHistory.save(modules))
+	assert.Nil(t, noHistory)
+}
+
+func TestSaveDurations(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "durations")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	file := filepath.Join(folder, "durations.json")
+
+	modules := createDurationsTestModules(t)
+	now := time.Now()
+	modules["a"].startTime, modules["a"].endTime = now.Add(-2*time.Minute), now
+
+	// Another run is saving its durations, the save must wait until it is done
+	unlock, err := util.LockFile(file + ".lock")
+	assert.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- saveDurations(file, modules) }()
+	select {
+	case <-done:
+		unlock()
+		t.Fatal("The durations should not be saved while another run holds the lock")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`{"apply": {"d": 60000000000}}`), 0644))
+	unlock()
+	assert.NoError(t, <-done)
+
+	durations, err := readDurations(file)
+	assert.NoError(t, err)
+	assert.Equal(t, moduleDurations{"apply": {"a": 2 * time.Minute, "d": time.Minute}}, durations, "The durations saved by the other run should be kept")
+
+	temporaryFiles, err := filepath.Glob(filepath.Join(folder, "*.tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, temporaryFiles, "The temporary file should have been renamed")
+}
@@ -0,0 +1,96 @@
+package configstack
+
+import (
+	"path/filepath"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Select the modules matching the include/exclude patterns of the options, expand the selection with the dependencies
+// and/or the dependents of the selected modules if requested and remove the dependencies on modules that are no longer
+// part of the stack. Patterns are relative to the working directory and a pattern matching a folder also selects all
+// modules under that folder.
+//
+// Excluded modules are never added back to the stack, even if they are dependencies or dependents of a selected module.
+func filterModules(modules []*TerraformModule, terragruntOptions *options.TerragruntOptions) []*TerraformModule {
+	if len(terragruntOptions.IncludeDirs) == 0 && len(terragruntOptions.ExcludeDirs) == 0 {
+		return modules
+	}
+
+	excluded := func(module *TerraformModule) bool {
+		return moduleMatches(module, terragruntOptions.ExcludeDirs, terragruntOptions)
+	}
+	selected := make(map[string]*TerraformModule, len(modules))
+	for _, module := range modules {
+		if (len(terragruntOptions.IncludeDirs) == 0 || moduleMatches(module, terragruntOptions.IncludeDirs, terragruntOptions)) && !excluded(module) {
+			selected[module.Path] = module
+		}
+	}
+
+	var expand func(module *TerraformModule, next func(*TerraformModule) []*TerraformModule)
+	expand = func(module *TerraformModule, next func(*TerraformModule) []*TerraformModule) {
+		for _, other := range next(module) {
+			if _, alreadySelected := selected[other.Path]; !alreadySelected && !excluded(other) {
+				selected[other.Path] = other
+				expand(other, next)
+			}
+		}
+	}
+
+	initialSelection := make([]*TerraformModule, 0, len(selected))
+	for _, module := range selected {
+		initialSelection = append(initialSelection, module)
+	}
+	if terragruntOptions.WithDependencies {
+		for _, module := range initialSelection {
+			expand(module, func(module *TerraformModule) []*TerraformModule { return module.Dependencies })
+		}
+	}
+	if terragruntOptions.WithDependents {
+		dependents := make(map[string][]*TerraformModule, len(modules))
+		for _, module := range modules {
+			for _, dependency := range module.Dependencies {
+				dependents[dependency.Path] = append(dependents[dependency.Path], module)
+			}
+		}
+		for _, module := range initialSelection {
+			expand(module, func(module *TerraformModule) []*TerraformModule { return dependents[module.Path] })
+		}
+	}
+
+	result := make([]*TerraformModule, 0, len(selected))
+	for _, module := range modules {
+		if _, isSelected := selected[module.Path]; !isSelected {
+			terragruntOptions.Logger.Debugf("Module %s excluded from the stack", util.GetPathRelativeToWorkingDir(module.Path))
+			continue
+		}
+		dependencies := make([]*TerraformModule, 0, len(module.Dependencies))
+		for _, dependency := range module.Dependencies {
+			if _, isSelected := selected[dependency.Path]; isSelected {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+		module.Dependencies = dependencies
+		result = append(result, module)
+	}
+	return result
+}
+
+// Returns true if the module (or one of its parent folders) matches one of the patterns
+func moduleMatches(module *TerraformModule, patterns []string, terragruntOptions *options.TerragruntOptions) bool {
+	relativePath, err := util.GetPathRelativeTo(module.Path, terragruntOptions.WorkingDir)
+	if err != nil {
+		relativePath = module.Path
+	}
+	for _, pattern := range patterns {
+		if filepath.IsAbs(pattern) {
+			if util.MatchGlobOrParent(pattern, module.Path) {
+				return true
+			}
+		} else if util.MatchGlobOrParent(pattern, relativePath) {
+			return true
+		}
+	}
+	return false
+}
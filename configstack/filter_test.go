@@ -0,0 +1,69 @@
+package configstack
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterModules(t *testing.T) {
+	t.Parallel()
+
+	// network/vpc <- network/subnets <- apps/api <- apps/web
+	//                                      ^------- apps/worker
+	// tools is independent
+	createModules := func() []*TerraformModule {
+		vpc := &TerraformModule{Path: "/stack/network/vpc"}
+		subnets := &TerraformModule{Path: "/stack/network/subnets", Dependencies: []*TerraformModule{vpc}}
+		api := &TerraformModule{Path: "/stack/apps/api", Dependencies: []*TerraformModule{subnets}}
+		web := &TerraformModule{Path: "/stack/apps/web", Dependencies: []*TerraformModule{api}}
+		worker := &TerraformModule{Path: "/stack/apps/worker", Dependencies: []*TerraformModule{api}}
+		tools := &TerraformModule{Path: "/stack/tools"}
+		return []*TerraformModule{vpc, subnets, api, web, worker, tools}
+	}
+
+	testCases := []struct {
+		name             string
+		include          []string
+		exclude          []string
+		withDependencies bool
+		withDependents   bool
+		expected         []string
+	}{
+		{"no filter", nil, nil, false, false, []string{"/stack/apps/api", "/stack/apps/web", "/stack/apps/worker", "/stack/network/subnets", "/stack/network/vpc", "/stack/tools"}},
+		{"include folder", []string{"network"}, nil, false, false, []string{"/stack/network/subnets", "/stack/network/vpc"}},
+		{"include glob", []string{"apps/w*"}, nil, false, false, []string{"/stack/apps/web", "/stack/apps/worker"}},
+		{"include absolute", []string{"/stack/tools"}, nil, false, false, []string{"/stack/tools"}},
+		{"exclude", nil, []string{"apps/**"}, false, false, []string{"/stack/network/subnets", "/stack/network/vpc", "/stack/tools"}},
+		{"include and exclude", []string{"apps"}, []string{"apps/worker"}, false, false, []string{"/stack/apps/api", "/stack/apps/web"}},
+		{"with dependencies", []string{"apps/api"}, nil, true, false, []string{"/stack/apps/api", "/stack/network/subnets", "/stack/network/vpc"}},
+		{"with dependents", []string{"network/subnets"}, nil, false, true, []string{"/stack/apps/api", "/stack/apps/web", "/stack/apps/worker", "/stack/network/subnets"}},
+		{"with both", []string{"apps/api"}, nil, true, true, []string{"/stack/apps/api", "/stack/apps/web", "/stack/apps/worker", "/stack/network/subnets", "/stack/network/vpc"}},
+		{"excluded are not expanded", []string{"network/vpc"}, []string{"apps/api"}, false, true, []string{"/stack/network/subnets", "/stack/network/vpc"}},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			terragruntOptions := options.NewTerragruntOptionsForTest("filter_test")
+			terragruntOptions.WorkingDir = "/stack"
+			terragruntOptions.IncludeDirs = tt.include
+			terragruntOptions.ExcludeDirs = tt.exclude
+			terragruntOptions.WithDependencies = tt.withDependencies
+			terragruntOptions.WithDependents = tt.withDependents
+
+			actual := []string{}
+			for _, module := range filterModules(createModules(), terragruntOptions) {
+				actual = append(actual, module.Path)
+				for _, dependency := range module.Dependencies {
+					assert.Contains(t, tt.expected, dependency.Path, "Dependency %s of %s should have been removed", dependency.Path, module.Path)
+				}
+			}
+			sort.Strings(actual)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -1,51 +1,178 @@
 package configstack
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/coveooss/terragrunt/v2/util"
 )
 
-// checkForCycles checks for dependency cycles in the given list of modules and return an error if one is found
+// checkForCycles checks for dependency cycles in the given list of modules and return an error listing all of them
+//
+// The strongly connected components of the module graph are computed with Tarjan's algorithm (linear in the number of
+// modules and dependencies), each component containing more than one module (or a module depending on itself) contains
+// at least one cycle and the shortest one going through its first module is reported. Other cycles of the same
+// component are not enumerated (their number can be exponential), they are revealed once the reported one is fixed.
 func checkForCycles(modules []*TerraformModule) error {
-	visitedPaths := []string{}
-	currentTraversalPaths := []string{}
+	var cycles errDependencyCycle
+	for _, component := range stronglyConnectedComponents(modules) {
+		if cycle := findCycle(component); cycle != nil {
+			cycles = append(cycles, cycle)
+		}
+	}
+	if len(cycles) == 0 {
+		return nil
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0].From < cycles[j][0].From })
+	return tgerrors.WithStackTrace(cycles)
+}
+
+// Returns the strongly connected components of the graph formed by the modules and their dependencies
+// See https://en.wikipedia.org/wiki/Tarjan%27s_strongly_connected_components_algorithm
+func stronglyConnectedComponents(modules []*TerraformModule) [][]*TerraformModule {
+	type nodeState struct {
+		index, lowLink int
+		onStack        bool
+	}
+	var (
+		states     = make(map[*TerraformModule]*nodeState, len(modules))
+		stack      []*TerraformModule
+		components [][]*TerraformModule
+		connect    func(module *TerraformModule)
+	)
+
+	connect = func(module *TerraformModule) {
+		state := &nodeState{len(states), len(states), true}
+		states[module] = state
+		stack = append(stack, module)
+
+		for _, dependency := range module.Dependencies {
+			if dependencyState, visited := states[dependency]; !visited {
+				connect(dependency)
+				if lowLink := states[dependency].lowLink; lowLink < state.lowLink {
+					state.lowLink = lowLink
+				}
+			} else if dependencyState.onStack && dependencyState.index < state.lowLink {
+				state.lowLink = dependencyState.index
+			}
+		}
+
+		if state.lowLink == state.index {
+			// The module is the root of a component, the component is made of the modules above it on the stack
+			var component []*TerraformModule
+			for {
+				last := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				states[last].onStack = false
+				component = append(component, last)
+				if last == module {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
 
 	for _, module := range modules {
-		err := checkForCyclesUsingDepthFirstSearch(module, &visitedPaths, &currentTraversalPaths)
-		if err != nil {
-			return err
+		if _, visited := states[module]; !visited {
+			connect(module)
 		}
 	}
+	return components
+}
 
+// Returns the shortest cycle starting from the module with the lowest path of the strongly connected component (nil if
+// the component is a single module that doesn't depend on itself)
+func findCycle(component []*TerraformModule) dependencyCycle {
+	members := make(map[*TerraformModule]bool, len(component))
+	start := component[0]
+	for _, module := range component {
+		members[module] = true
+		if module.Path < start.Path {
+			start = module
+		}
+	}
+
+	// Breadth-first search from the start module until we get back to it
+	previous := make(map[*TerraformModule]*TerraformModule, len(component))
+	queue := []*TerraformModule{start}
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		for _, dependency := range module.Dependencies {
+			if dependency == start {
+				var cycle dependencyCycle
+				for from, to := module, start; ; from, to = previous[from], from {
+					cycle = append(dependencyCycle{newDependencyEdge(from, to)}, cycle...)
+					if from == start {
+						return cycle
+					}
+				}
+			}
+			if _, seen := previous[dependency]; members[dependency] && !seen {
+				previous[dependency] = module
+				queue = append(queue, dependency)
+			}
+		}
+	}
 	return nil
 }
 
-// Check for cycles using a depth-first-search as described here:
-// https://en.wikipedia.org/wiki/Topological_sorting#Depth-first_search
-//
-// Note that this method uses two lists, visitedPaths, and currentTraversalPaths, to track what nodes have already been
-// seen. We need to use lists to maintain ordering so we can show the proper order of paths in a cycle. Of course, a
-// list doesn't perform well with repeated contains() and remove() checks, so ideally we'd use an ordered Map (e.g.
-// Java's LinkedHashMap), but since Go doesn't have such a data structure built-in, and our lists are going to be very
-// small (at most, a few dozen paths), there is no point in worrying about performance.
-func checkForCyclesUsingDepthFirstSearch(module *TerraformModule, visitedPaths *[]string, currentTraversalPaths *[]string) error {
-	if util.ListContainsElement(*visitedPaths, module.Path) {
-		return nil
-	}
+// dependencyEdge represents the dependency of a module on another one along with the file that introduces it (the
+// config file or the included file that declares it or the terraform file from which it has been inferred)
+type dependencyEdge struct {
+	From, To, ConfigFile string
+	Inferred             bool
+}
 
-	if util.ListContainsElement(*currentTraversalPaths, module.Path) {
-		return tgerrors.WithStackTrace(errDependencyCycle(append(*currentTraversalPaths, module.Path)))
+func newDependencyEdge(from, to *TerraformModule) dependencyEdge {
+	if file, inferred := from.inferredFrom[to]; inferred {
+		return dependencyEdge{from.Path, to.Path, file, true}
 	}
+	configFile := from.Config.DependencySources()[to.Path]
+	if configFile == "" && from.TerragruntOptions != nil {
+		configFile = from.TerragruntOptions.TerragruntConfigPath
+	} else if configFile == "" {
+		configFile = from.Path
+	}
+	return dependencyEdge{from.Path, to.Path, configFile, false}
+}
 
-	*currentTraversalPaths = append(*currentTraversalPaths, module.Path)
-	for _, dependency := range module.Dependencies {
-		if err := checkForCyclesUsingDepthFirstSearch(dependency, visitedPaths, currentTraversalPaths); err != nil {
-			return err
-		}
+func (edge dependencyEdge) String() string {
+	declaration := "declared in"
+	if edge.Inferred {
+		declaration = "inferred from the terraform_remote_state in"
 	}
+	return fmt.Sprintf("%s depends on %s (%s %s)",
+		util.GetPathRelativeToWorkingDirMax(edge.From, 3),
+		util.GetPathRelativeToWorkingDirMax(edge.To, 3),
+		declaration,
+		util.GetPathRelativeToWorkingDir(edge.ConfigFile),
+	)
+}
 
-	*visitedPaths = append(*visitedPaths, module.Path)
-	*currentTraversalPaths = util.RemoveElementFromList(*currentTraversalPaths, module.Path)
+// dependencyCycle is a list of dependencies where the last module depends on the first one
+type dependencyCycle []dependencyEdge
 
-	return nil
+// Returns the paths of the modules in the cycle (the first module is repeated at the end)
+func (cycle dependencyCycle) paths() []string {
+	result := make([]string, 0, len(cycle)+1)
+	for _, edge := range cycle {
+		result = append(result, edge.From)
+	}
+	return append(result, cycle[0].From)
+}
+
+func (cycle dependencyCycle) String() string {
+	paths := cycle.paths()
+	for i := range paths {
+		paths[i] = util.GetPathRelativeToWorkingDirMax(paths[i], 3)
+	}
+	lines := []string{strings.Join(paths, " -> ")}
+	for _, edge := range cycle {
+		lines = append(lines, "  "+edge.String())
+	}
+	return strings.Join(lines, "\n")
 }
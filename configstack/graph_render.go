@@ -0,0 +1,114 @@
+package configstack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// DOT renders the dependency graph of the modules in the Graphviz DOT format (edges go from a module to its dependencies)
+func (modules SimpleTerraformModules) DOT() string {
+	var builder strings.Builder
+	builder.WriteString("digraph stack {\n")
+	for _, module := range modules {
+		fmt.Fprintf(&builder, "  %q;\n", module.Path)
+	}
+	for _, module := range modules {
+		for _, dependency := range module.Dependencies {
+			fmt.Fprintf(&builder, "  %q -> %q;\n", module.Path, dependency)
+		}
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// Mermaid renders the dependency graph of the modules as a Mermaid flowchart (edges go from a module to its dependencies)
+func (modules SimpleTerraformModules) Mermaid() string {
+	ids := make(map[string]string, len(modules))
+	id := func(path string) string {
+		if _, exist := ids[path]; !exist {
+			ids[path] = fmt.Sprintf("m%d", len(ids))
+		}
+		return ids[path]
+	}
+
+	var builder strings.Builder
+	builder.WriteString("graph TD\n")
+	declare := func(path string) {
+		if _, exist := ids[path]; !exist {
+			fmt.Fprintf(&builder, "  %s[\"%s\"]\n", id(path), strings.ReplaceAll(path, `"`, "#quot;"))
+		}
+	}
+	for _, module := range modules {
+		declare(module.Path)
+	}
+	for _, module := range modules {
+		for _, dependency := range module.Dependencies {
+			// Dependencies that are not part of the modules must also be declared to get a proper label
+			declare(dependency)
+			fmt.Fprintf(&builder, "  %s --> %s\n", id(module.Path), id(dependency))
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// Layers groups the modules into the successive waves that would be executed in parallel by a -all command (if there
+// are enough workers). A module is in the layer following the last layer of its dependencies (or of its dependents in
+// reverse order). Dependencies that are not part of the modules are ignored.
+func (modules SimpleTerraformModules) Layers(order dependencyOrder) [][]string {
+	predecessors := make(map[string][]string, len(modules))
+	for _, module := range modules {
+		predecessors[module.Path] = nil
+	}
+	for _, module := range modules {
+		for _, dependency := range module.Dependencies {
+			if _, exist := predecessors[dependency]; !exist || dependency == module.Path {
+				continue
+			}
+			if order == ReverseOrder {
+				predecessors[dependency] = append(predecessors[dependency], module.Path)
+			} else {
+				predecessors[module.Path] = append(predecessors[module.Path], dependency)
+			}
+		}
+	}
+
+	levels := make(map[string]int, len(modules))
+	var level func(path string, visiting map[string]bool) int
+	level = func(path string, visiting map[string]bool) int {
+		if result, found := levels[path]; found {
+			return result
+		}
+		visiting[path] = true
+		result := 0
+		for _, predecessor := range predecessors[path] {
+			if visiting[predecessor] {
+				// There is a cycle, we cannot determine the real order
+				continue
+			}
+			if predecessorLevel := level(predecessor, visiting) + 1; predecessorLevel > result {
+				result = predecessorLevel
+			}
+		}
+		delete(visiting, path)
+		levels[path] = result
+		return result
+	}
+
+	var layers [][]string
+	for _, module := range modules {
+		moduleLevel := level(module.Path, map[string]bool{})
+		for len(layers) <= moduleLevel {
+			layers = append(layers, []string{})
+		}
+		if !util.ListContainsElement(layers[moduleLevel], module.Path) {
+			layers[moduleLevel] = append(layers[moduleLevel], module.Path)
+		}
+	}
+	for _, layer := range layers {
+		sort.Strings(layer)
+	}
+	return layers
+}
@@ -0,0 +1,73 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a <- b <- d
+// a <- c <- d
+// e
+var renderTestModules = SimpleTerraformModules{
+	{Path: "a"},
+	{Path: "b", Dependencies: []string{"a"}},
+	{Path: "c", Dependencies: []string{"a"}},
+	{Path: "d", Dependencies: []string{"b", "c", "external"}},
+	{Path: "e"},
+}
+
+func TestSimpleTerraformModulesDOT(t *testing.T) {
+	t.Parallel()
+
+	expected := `digraph stack {
+  "a";
+  "b";
+  "c";
+  "d";
+  "e";
+  "b" -> "a";
+  "c" -> "a";
+  "d" -> "b";
+  "d" -> "c";
+  "d" -> "external";
+}`
+	assert.Equal(t, expected, renderTestModules.DOT())
+}
+
+func TestSimpleTerraformModulesMermaid(t *testing.T) {
+	t.Parallel()
+
+	expected := `graph TD
+  m0["a"]
+  m1["b"]
+  m2["c"]
+  m3["d"]
+  m4["e"]
+  m1 --> m0
+  m2 --> m0
+  m3 --> m1
+  m3 --> m2
+  m5["external"]
+  m3 --> m5`
+	assert.Equal(t, expected, renderTestModules.Mermaid())
+}
+
+func TestSimpleTerraformModulesLayers(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, [][]string{{"a", "e"}, {"b", "c"}, {"d"}}, renderTestModules.Layers(NormalOrder))
+	assert.Equal(t, [][]string{{"d", "e"}, {"b", "c"}, {"a"}}, renderTestModules.Layers(ReverseOrder))
+}
+
+func TestSimpleTerraformModulesLayersWithCycle(t *testing.T) {
+	t.Parallel()
+
+	modules := SimpleTerraformModules{
+		{Path: "a", Dependencies: []string{"b"}},
+		{Path: "b", Dependencies: []string{"a"}},
+	}
+	layers := modules.Layers(NormalOrder)
+	assert.Len(t, layers, 2)
+	assert.ElementsMatch(t, []string{"a", "b"}, append(layers[0], layers[1]...))
+}
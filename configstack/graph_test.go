@@ -1,8 +1,10 @@
 package configstack
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,9 +60,17 @@ func TestCheckForCycles(t *testing.T) {
 	m := &TerraformModule{Path: "m", Dependencies: []*TerraformModule{n}}
 	l.Dependencies = append(l.Dependencies, m)
 
+	// p -> q -> r -> p
+	//      |
+	//       --> p
+	p := &TerraformModule{Path: "p", Dependencies: []*TerraformModule{}}
+	r := &TerraformModule{Path: "r", Dependencies: []*TerraformModule{p}}
+	q := &TerraformModule{Path: "q", Dependencies: []*TerraformModule{r, p}}
+	p.Dependencies = append(p.Dependencies, q)
+
 	testCases := []struct {
 		modules  []*TerraformModule
-		expected errDependencyCycle
+		expected [][]string
 	}{
 		{[]*TerraformModule{}, nil},
 		{[]*TerraformModule{a}, nil},
@@ -69,10 +79,13 @@ func TestCheckForCycles(t *testing.T) {
 		{[]*TerraformModule{a, b, f}, nil},
 		{[]*TerraformModule{a, e, g}, nil},
 		{[]*TerraformModule{a, b, c, e, f, g, h}, nil},
-		{[]*TerraformModule{i}, errDependencyCycle([]string{"i", "i"})},
-		{[]*TerraformModule{j, k}, errDependencyCycle([]string{"j", "k", "j"})},
-		{[]*TerraformModule{l, o, n, m}, errDependencyCycle([]string{"l", "m", "n", "o", "l"})},
-		{[]*TerraformModule{a, l, b, o, n, f, m, h}, errDependencyCycle([]string{"l", "m", "n", "o", "l"})},
+		{[]*TerraformModule{i}, [][]string{{"i", "i"}}},
+		{[]*TerraformModule{j, k}, [][]string{{"j", "k", "j"}}},
+		{[]*TerraformModule{k, j}, [][]string{{"j", "k", "j"}}},
+		{[]*TerraformModule{l, o, n, m}, [][]string{{"l", "m", "n", "o", "l"}}},
+		{[]*TerraformModule{a, l, b, o, n, f, m, h}, [][]string{{"l", "m", "n", "o", "l"}}},
+		{[]*TerraformModule{r, q, p}, [][]string{{"p", "q", "p"}}},
+		{[]*TerraformModule{m, h, k, i}, [][]string{{"i", "i"}, {"j", "k", "j"}, {"l", "m", "n", "o", "l"}}},
 	}
 
 	for _, testCase := range testCases {
@@ -81,7 +94,27 @@ func TestCheckForCycles(t *testing.T) {
 			assert.Nil(t, actual)
 		} else if assert.NotNil(t, actual, "For modules %v", testCase.modules) {
 			actualErr := tgerrors.Unwrap(actual).(errDependencyCycle)
-			assert.Equal(t, []string(testCase.expected), []string(actualErr), "For modules %v", testCase.modules)
+			cycles := make([][]string, len(actualErr))
+			for i := range actualErr {
+				cycles[i] = actualErr[i].paths()
+			}
+			assert.Equal(t, testCase.expected, cycles, "For modules %v", testCase.modules)
 		}
 	}
 }
+
+func TestDependencyCycleError(t *testing.T) {
+	t.Parallel()
+
+	a := &TerraformModule{Path: "a", TerragruntOptions: options.NewTerragruntOptionsForTest("a/terragrunt.hcl")}
+	b := &TerraformModule{Path: "b", Dependencies: []*TerraformModule{a}, TerragruntOptions: options.NewTerragruntOptionsForTest("b/terragrunt.hcl")}
+	a.Dependencies = []*TerraformModule{b}
+
+	err := checkForCycles([]*TerraformModule{a, b})
+	assert.EqualError(t, err, strings.Join([]string{
+		"Found 1 dependency cycle(s) between modules (only one cycle is shown for each group of interdependent modules, fixing it may reveal other ones):",
+		"a -> b -> a",
+		"  a depends on b (declared in a/terragrunt.hcl)",
+		"  b depends on a (declared in b/terragrunt.hcl)",
+	}, "\n"))
+}
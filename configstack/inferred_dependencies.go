@@ -0,0 +1,125 @@
+package configstack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/coveooss/terragrunt/v2/remote"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// InferredDependency is a dependency between two modules that is not declared in the configuration, but found by
+// matching a terraform_remote_state data source of the module with the remote state of the other module
+type InferredDependency struct {
+	Module     *TerraformModule
+	Dependency *TerraformModule
+	DataSource string
+	File       string // The terraform file that declares the data source
+}
+
+func (dependency InferredDependency) String() string {
+	return fmt.Sprintf("Module %s reads the state of %s (data.terraform_remote_state.%s) without depending on it",
+		util.GetPathRelativeToWorkingDirMax(dependency.Module.Path, 3),
+		util.GetPathRelativeToWorkingDirMax(dependency.Dependency.Path, 3),
+		dependency.DataSource,
+	)
+}
+
+// The attributes of the backend configuration that identify the state of a module
+var remoteStateIdentifiers = []string{"bucket", "key", "prefix", "storage_account_name", "container_name"}
+
+// InferredDependencies returns the dependencies between the modules of the stack that are not declared in their configuration
+func (stack *Stack) InferredDependencies() []InferredDependency {
+	return inferDependencies(stack.Modules)
+}
+
+// Analyze the terraform_remote_state data sources of the modules to find the undeclared dependencies between them
+func inferDependencies(modules []*TerraformModule) []InferredDependency {
+	var result []InferredDependency
+	for _, module := range modules {
+		references, err := util.LoadRemoteStateReferences(module.terraformFolder(), module.Config.Inputs)
+		if err != nil {
+			module.TerragruntOptions.Logger.Debugf("Unable to analyze the terraform_remote_state data sources of %s: %v", module.Path, err)
+			continue
+		}
+		for _, reference := range references {
+			for _, other := range modules {
+				if other != module && remoteStateMatches(reference, other.Config.RemoteState) && !module.dependsOn(other, map[string]bool{}) {
+					result = append(result, InferredDependency{module, other, reference.Name, reference.File})
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Module.Path != result[j].Module.Path {
+			return result[i].Module.Path < result[j].Module.Path
+		}
+		return result[i].Dependency.Path < result[j].Dependency.Path
+	})
+	return result
+}
+
+// Add the inferred dependencies to the modules (used when --terragrunt-infer-dependencies is specified)
+func addInferredDependencies(modules []*TerraformModule) {
+	for _, inferred := range inferDependencies(modules) {
+		if inferred.Module.dependsOn(inferred.Dependency, map[string]bool{}) {
+			// The same dependency could be inferred from several data sources
+			continue
+		}
+		inferred.Module.TerragruntOptions.Logger.Infof("Adding inferred dependency: %v", inferred)
+		inferred.Module.Dependencies = append(inferred.Module.Dependencies, inferred.Dependency)
+		if inferred.Module.inferredFrom == nil {
+			inferred.Module.inferredFrom = map[*TerraformModule]string{}
+		}
+		inferred.Module.inferredFrom[inferred.Dependency] = inferred.File
+	}
+}
+
+// Returns true if the backend configuration of the data source designates the remote state
+func remoteStateMatches(reference util.RemoteStateReference, state *remote.State) bool {
+	if state == nil || state.Backend != reference.Backend {
+		return false
+	}
+	matched := false
+	for _, name := range remoteStateIdentifiers {
+		expected, defined := state.Config[name]
+		if !defined {
+			continue
+		}
+		if value, found := reference.Config[name]; !found || fmt.Sprint(value) != fmt.Sprint(expected) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// Returns true if the module depends (directly or not) on the other module
+func (module *TerraformModule) dependsOn(other *TerraformModule, visited map[string]bool) bool {
+	if visited[module.Path] {
+		return false
+	}
+	visited[module.Path] = true
+	for _, dependency := range module.Dependencies {
+		if dependency == other || dependency.dependsOn(other, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the local folder containing the terraform files of the module (the module folder if the source is remote)
+func (module *TerraformModule) terraformFolder() string {
+	if module.Config.Terraform != nil && module.Config.Terraform.Source != "" {
+		source := module.Config.Terraform.Source
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(module.Path, source)
+		}
+		if stat, err := os.Stat(source); err == nil && stat.IsDir() {
+			return source
+		}
+	}
+	return module.Path
+}
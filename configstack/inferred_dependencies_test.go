@@ -0,0 +1,76 @@
+package configstack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferDependencies(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "infer-dependencies")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	createModule := func(name, code string, dependencies ...*TerraformModule) *TerraformModule {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(path, 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(path, "main.tf"), []byte(code), 0644))
+		return &TerraformModule{
+			Path:         path,
+			Dependencies: dependencies,
+			Config: config.TerragruntConfig{
+				Inputs: map[string]interface{}{"env": "dev"},
+				RemoteState: &remote.State{Backend: "s3", Config: map[string]interface{}{
+					"bucket": "states",
+					"key":    "dev/" + name + "/terraform.tfstate",
+					"region": "us-east-1",
+				}},
+			},
+			TerragruntOptions: options.NewTerragruntOptionsForTest(filepath.Join(path, "terragrunt.hcl")),
+		}
+	}
+	remoteState := func(name string) string {
+		return `
+			data "terraform_remote_state" "` + name + `" {
+				backend = "s3"
+				config = {
+					bucket = "states"
+					key    = "${var.env}/` + name + `/terraform.tfstate"
+				}
+			}
+		`
+	}
+	vpc := createModule("vpc", "")
+	db := createModule("db", `variable "env" {}`+remoteState("vpc"), vpc)
+	app := createModule("app", `variable "env" {}`+remoteState("db")+remoteState("vpc"))
+	other := createModule("other", `data "terraform_remote_state" "unknown" {
+		backend = "s3"
+		config = {
+			bucket = "states"
+			key    = "prod/vpc/terraform.tfstate"
+		}
+	}`)
+	modules := []*TerraformModule{vpc, db, app, other}
+
+	inferred := inferDependencies(modules)
+	if assert.Len(t, inferred, 2) {
+		assert.Equal(t, InferredDependency{app, db, "db", filepath.Join(app.Path, "main.tf")}, inferred[0])
+		assert.Equal(t, InferredDependency{app, vpc, "vpc", filepath.Join(app.Path, "main.tf")}, inferred[1])
+	}
+
+	// Once the dependency on db is added, vpc is an indirect dependency of app
+	addInferredDependencies(modules)
+	assert.Equal(t, []*TerraformModule{db}, app.Dependencies)
+	assert.Empty(t, inferDependencies(modules))
+
+	// The dependency cycles report the file from which the dependency has been inferred
+	assert.Equal(t, dependencyEdge{app.Path, db.Path, filepath.Join(app.Path, "main.tf"), true}, newDependencyEdge(app, db))
+}
@@ -0,0 +1,176 @@
+package configstack
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Possible outcomes of a module recorded in the run journal
+const (
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+	outcomeSkipped   = "skipped"
+	outcomeCancelled = "cancelled"
+	outcomeTimedOut  = "timed_out"
+)
+
+// JournalEntry represents the outcome of a single module recorded in the journal of a -all run
+type JournalEntry struct {
+	Path     string        `json:"path"`
+	Command  string        `json:"command"`
+	Status   string        `json:"status"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	RunID    string        `json:"run_id"`
+	Time     time.Time     `json:"time"`
+}
+
+// runJournal persists the outcome of each module as soon as it is completed. This allows a subsequent execution
+// to resume an interrupted or failed run by skipping the modules that have already succeeded.
+type runJournal struct {
+	id        string // The id of the run that initiated the journal
+	path      string
+	runID     string // The id of the current run
+	mutex     sync.Mutex
+	completed map[string]JournalEntry // The last successful outcomes recorded by the run being resumed
+}
+
+// The journals that have not been updated for this period are deleted when a new run starts
+const journalRetention = 7 * 24 * time.Hour
+
+// JournalFolder returns the folder where the run journals are saved
+func JournalFolder() string {
+	return util.GetTempDownloadFolder("terragrunt-cache", "journals")
+}
+
+// Open the journal for the current run. If the user asked to resume a previous run, the journal of that run is
+// reloaded and the new results are appended to it. No journal is kept if there is no run id.
+func openJournal(terragruntOptions *options.TerragruntOptions) (*runJournal, error) {
+	runID := terragruntOptions.Env[options.EnvRunID]
+	journalID := runID
+	if terragruntOptions.ResumeRunID != "" {
+		journalID = terragruntOptions.ResumeRunID
+	}
+	if journalID == "" {
+		return nil, nil
+	}
+
+	journal := &runJournal{
+		id:        journalID,
+		path:      filepath.Join(JournalFolder(), journalID+".jsonl"),
+		runID:     runID,
+		completed: map[string]JournalEntry{},
+	}
+	pruneJournals(JournalFolder(), journal.path, journalRetention, terragruntOptions)
+
+	if terragruntOptions.ResumeRunID != "" {
+		if err := journal.load(); err != nil {
+			return nil, err
+		}
+	}
+	return journal, nil
+}
+
+// Delete the journals of the previous runs that have not been updated since the retention period (except the journal
+// of the current run)
+func pruneJournals(folder, current string, retention time.Duration, terragruntOptions *options.TerragruntOptions) {
+	files, err := filepath.Glob(filepath.Join(folder, "*.jsonl"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || file == current || time.Since(info.ModTime()) < retention {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			terragruntOptions.Logger.Debugf("Unable to delete the old run journal %s: %v", file, err)
+		}
+	}
+}
+
+// Load the entries of an existing journal, only the last outcome of each module is considered
+func (journal *runJournal) load() error {
+	file, err := os.Open(journal.path)
+	if os.IsNotExist(err) {
+		return tgerrors.WithStackTrace(errJournalNotFound(journal.path))
+	} else if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The last line may be incomplete if the previous run has been killed while writing
+			continue
+		}
+		if entry.Status == outcomeSucceeded {
+			journal.completed[entry.Path] = entry
+		} else {
+			delete(journal.completed, entry.Path)
+		}
+	}
+	return tgerrors.WithStackTrace(scanner.Err())
+}
+
+// Returns true if the module has already succeeded with the same command in the run being resumed
+func (journal *runJournal) alreadySucceeded(module *TerraformModule) bool {
+	if journal == nil {
+		return false
+	}
+	entry, found := journal.completed[module.Path]
+	return found && entry.Command == util.IndexOrDefault(module.TerragruntOptions.TerraformCliArgs, 0, "")
+}
+
+// Append the outcome of the module to the journal
+func (journal *runJournal) record(module *runningModule) error {
+	if journal == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(JournalEntry{
+		Path:     module.Module.Path,
+		Command:  util.IndexOrDefault(module.Module.TerragruntOptions.TerraformCliArgs, 0, ""),
+		Status:   module.outcome(),
+		ExitCode: module.exitCode(),
+		Duration: module.duration(),
+		RunID:    journal.runID,
+		Time:     time.Now(),
+	})
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(journal.path), 0755); err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	file, err := os.OpenFile(journal.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintln(file, string(line))
+	return tgerrors.WithStackTrace(err)
+}
+
+// Custom error types
+
+type errJournalNotFound string
+
+func (err errJournalNotFound) Error() string {
+	return fmt.Sprintf("Unable to resume the run, the journal %s does not exist", string(err))
+}
@@ -0,0 +1,116 @@
+package configstack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/util"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunModulesResumeSkipsSucceededModules(t *testing.T) {
+	t.Parallel()
+
+	runID := fmt.Sprint(xid.New())
+	defer os.Remove(filepath.Join(JournalFolder(), runID+".jsonl"))
+
+	createModules := func(currentRunID, resumeRunID string, errB error, aRan, bRan, cRan *bool) []*TerraformModule {
+		setOptions := func(opts *options.TerragruntOptions) *options.TerragruntOptions {
+			opts.TerraformCliArgs = []string{"apply"}
+			opts.Env[options.EnvRunID] = currentRunID
+			opts.ResumeRunID = resumeRunID
+			return opts
+		}
+		moduleA := &TerraformModule{
+			Path:              "a",
+			Dependencies:      []*TerraformModule{},
+			Config:            config.TerragruntConfig{},
+			TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("a", nil, aRan)),
+		}
+		moduleB := &TerraformModule{
+			Path:              "b",
+			Dependencies:      []*TerraformModule{moduleA},
+			Config:            config.TerragruntConfig{},
+			TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("b", errB, bRan)),
+		}
+		moduleC := &TerraformModule{
+			Path:              "c",
+			Dependencies:      []*TerraformModule{moduleB},
+			Config:            config.TerragruntConfig{},
+			TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("c", nil, cRan)),
+		}
+		return []*TerraformModule{moduleA, moduleB, moduleC}
+	}
+
+	aRan, bRan, cRan := false, false, false
+	expectedErrB := fmt.Errorf("Expected error for module b")
+	err := runModules(createModules(runID, "", expectedErrB, &aRan, &bRan, &cRan))
+	assert.Error(t, err)
+	assert.True(t, aRan)
+	assert.True(t, bRan)
+	assert.False(t, cRan)
+
+	aRan, bRan, cRan = false, false, false
+	err = runModules(createModules(fmt.Sprint(xid.New()), runID, nil, &aRan, &bRan, &cRan))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, aRan, "Module a already succeeded and should have been skipped")
+	assert.True(t, bRan)
+	assert.True(t, cRan)
+
+	// Everything has succeeded, so nothing should run on a subsequent resume
+	aRan, bRan, cRan = false, false, false
+	err = runModules(createModules(fmt.Sprint(xid.New()), runID, nil, &aRan, &bRan, &cRan))
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	assert.False(t, aRan || bRan || cRan)
+}
+
+func TestRunModulesResumeUnknownRun(t *testing.T) {
+	t.Parallel()
+
+	aRan := false
+	opts := optionsWithMockTerragruntCommand("a", nil, &aRan)
+	opts.ResumeRunID = fmt.Sprint(xid.New())
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: opts,
+	}
+
+	err := runModules([]*TerraformModule{moduleA})
+	assert.Error(t, err)
+	assert.False(t, aRan)
+}
+
+func TestPruneJournals(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "journals")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte("{}\n"), 0644))
+		modified := time.Now().Add(-age)
+		assert.NoError(t, os.Chtimes(path, modified, modified))
+		return path
+	}
+	old := write("old.jsonl", 8*24*time.Hour)
+	recent := write("recent.jsonl", time.Hour)
+	resumed := write("resumed.jsonl", 8*24*time.Hour)
+	other := write("other.txt", 8*24*time.Hour)
+
+	pruneJournals(folder, resumed, journalRetention, options.NewTerragruntOptionsForTest(""))
+	assert.False(t, util.FileExists(old), "The old journals should be deleted")
+	assert.True(t, util.FileExists(recent))
+	assert.True(t, util.FileExists(resumed), "The journal of the current run should be kept")
+	assert.True(t, util.FileExists(other))
+}
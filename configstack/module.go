@@ -3,7 +3,10 @@ package configstack
 import (
 	"fmt"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/utils"
@@ -14,6 +17,14 @@ import (
 	"github.com/coveooss/terragrunt/v2/util"
 )
 
+// Supported policies for the external dependencies (dependencies outside of the root folders of the stack)
+const (
+	ExternalDependenciesPrompt  = "prompt"
+	ExternalDependenciesSkip    = "skip"
+	ExternalDependenciesInclude = "include"
+	ExternalDependenciesFail    = "fail"
+)
+
 // TerraformModule represents a single module (i.e. folder with Terraform templates), including the Terragrunt configuration for that
 // module and the list of other modules that this module depends on
 type TerraformModule struct {
@@ -22,6 +33,9 @@ type TerraformModule struct {
 	Config               config.TerragruntConfig
 	TerragruntOptions    *options.TerragruntOptions
 	AssumeAlreadyApplied bool
+
+	inferredFrom map[*TerraformModule]string // The terraform file from which each inferred dependency has been found
+	planSummary  *PlanSummary                // The resource changes of the plan saved by plan-all (nil if not available)
 }
 
 // Render this module as a human-readable string
@@ -70,7 +84,8 @@ func (modules SimpleTerraformModules) MakeRelative() (result SimpleTerraformModu
 }
 
 // ResolveTerraformModules goes through each of the given Terragrunt configuration files and resolve the module that configuration file represents
-// into a TerraformModule struct. Return the list of these TerraformModule structures.
+// into a TerraformModule struct. Return the list of these TerraformModule structures (filtered by the include/exclude
+// patterns specified in the options).
 func ResolveTerraformModules(terragruntConfigPaths []string, terragruntOptions *options.TerragruntOptions) ([]*TerraformModule, error) {
 	canonicalTerragruntConfigPaths, err := util.CanonicalPaths(terragruntConfigPaths, ".")
 	if err != nil {
@@ -99,7 +114,14 @@ func ResolveTerraformModules(terragruntConfigPaths []string, terragruntOptions *
 	if err != nil {
 		return []*TerraformModule{}, err
 	}
-	return crosslinkDependencies(mergeMaps(modules, externalDependencies), canonicalTerragruntConfigPaths)
+	crossLinkedModules, err := crosslinkDependencies(mergeMaps(modules, externalDependencies), canonicalTerragruntConfigPaths)
+	if err != nil {
+		return crossLinkedModules, err
+	}
+	if terragruntOptions.InferDependencies {
+		addInferredDependencies(crossLinkedModules)
+	}
+	return filterModules(crossLinkedModules, terragruntOptions), nil
 }
 
 // Go through each of the given Terragrunt configuration files and resolve the module that configuration file represents
@@ -108,20 +130,51 @@ func ResolveTerraformModules(terragruntConfigPaths []string, terragruntOptions *
 //
 // resolveExternal is used to exclude modules that don't contain terraform files. This is used to avoid requirements of
 // adding terragrunt.ignore when a parent folder doesn't have terraform files to deploy by itself.
+//
+// The configurations are parsed concurrently (up to the number of workers) and the errors of all modules are returned.
 func resolveModules(canonicalTerragruntConfigPaths []string, terragruntOptions *options.TerragruntOptions, resolveExternal bool) (map[string]*TerraformModule, error) {
-	moduleMap := map[string]*TerraformModule{}
+	type resolvedModule struct {
+		module  *TerraformModule
+		tfFiles bool
+		err     error
+	}
+	results := make([]resolvedModule, len(canonicalTerragruntConfigPaths))
 
-	for _, terragruntConfigPath := range canonicalTerragruntConfigPaths {
-		if module, tfFiles, err := resolveTerraformModule(terragruntConfigPath, terragruntOptions); err == nil {
-			if resolveExternal && module != nil || tfFiles {
-				moduleMap[module.Path] = module
-			}
-		} else {
-			return moduleMap, err
+	nbWorkers := terragruntOptions.NbWorkers
+	if nbWorkers <= 0 {
+		nbWorkers = runtime.NumCPU()
+	}
+	semaphore := make(chan struct{}, nbWorkers)
+	var wg sync.WaitGroup
+	for i, terragruntConfigPath := range canonicalTerragruntConfigPaths {
+		wg.Add(1)
+		go func(result *resolvedModule, terragruntConfigPath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			result.module, result.tfFiles, result.err = resolveTerraformModule(terragruntConfigPath, terragruntOptions)
+		}(&results[i], terragruntConfigPath)
+	}
+	wg.Wait()
+
+	moduleMap := map[string]*TerraformModule{}
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, result.err)
+		} else if resolveExternal && result.module != nil || result.tfFiles {
+			moduleMap[result.module.Path] = result.module
 		}
 	}
 
-	return moduleMap, nil
+	switch len(errs) {
+	case 0:
+		return moduleMap, nil
+	case 1:
+		return moduleMap, errs[0]
+	default:
+		return moduleMap, tgerrors.WithStackTrace(errMulti{Errors: errs})
+	}
 }
 
 // Create a TerraformModule struct for the Terraform module specified by the given Terragrunt configuration file path.
@@ -133,10 +186,20 @@ func resolveTerraformModule(terragruntConfigPath string, terragruntOptions *opti
 		return
 	}
 
+	// The outputs of the dependencies are fetched when the module is executed, not while resolving the stack
 	opts := terragruntOptions.Clone(terragruntConfigPath)
-	_, terragruntConfig, err := config.ParseConfigFile(opts, config.IncludeConfig{Path: terragruntConfigPath})
-	if err != nil {
-		return
+	// The cache is validated against the original options since the parsing adds the inputs to the variables of opts
+	terragruntConfig := loadDiscoveryCache(terragruntConfigPath, terragruntOptions)
+	if terragruntConfig != nil {
+		terragruntOptions.Logger.Debugf("Using the discovery cache for %s", terragruntConfigPath)
+	} else {
+		opts.SkipDependencyOutputs = true
+		_, terragruntConfig, err = config.ParseConfigFile(opts, config.IncludeConfig{Path: terragruntConfigPath})
+		opts.SkipDependencyOutputs = false
+		if err != nil {
+			return
+		}
+		saveDiscoveryCache(terragruntConfigPath, terragruntConfig, terragruntOptions)
 	}
 
 	// Fix for https://github.com/gruntwork-io/terragrunt/issues/208
@@ -166,13 +229,22 @@ func resolveTerraformModule(terragruntConfigPath string, terragruntOptions *opti
 // Look through the dependencies of the modules in the given map and resolve the "external" dependency paths listed in
 // each modules config (i.e. those dependencies not in the given list of Terragrunt config canonical file paths).
 // These external dependencies are outside of the current working directory, which means they may not be part of the
-// environment the user is trying to apply-all or destroy-all. Therefore, this method also confirms whether the user wants
-// to actually apply those dependencies or just assume they are already applied. Note that this method will NOT fill in
-// the Dependencies field of the TerraformModule struct (see the crosslinkDependencies method for that).
+// environment the user is trying to apply-all or destroy-all. Therefore, this method also applies the external
+// dependencies policy (--terragrunt-external-dependencies) to determine whether these dependencies should be applied or
+// just assumed already applied. Note that this method will NOT fill in the Dependencies field of the TerraformModule
+// struct (see the crosslinkDependencies method for that).
 func resolveExternalDependenciesForModules(canonicalTerragruntConfigPaths []string, moduleMap map[string]*TerraformModule, terragruntOptions *options.TerragruntOptions) (map[string]*TerraformModule, error) {
 	allExternalDependencies := map[string]*TerraformModule{}
+	var forbidden errExternalDependencies
 
+	pending := make([]*TerraformModule, 0, len(moduleMap))
 	for _, module := range moduleMap {
+		pending = append(pending, module)
+	}
+	for len(pending) > 0 {
+		module := pending[0]
+		pending = pending[1:]
+
 		externalDependencies, err := resolveExternalDependenciesForModule(module, canonicalTerragruntConfigPaths, terragruntOptions)
 		if err != nil {
 			return externalDependencies, err
@@ -201,6 +273,14 @@ func resolveExternalDependenciesForModules(canonicalTerragruntConfigPaths []stri
 				continue
 			}
 
+			if terragruntOptions.ExternalDependencies == ExternalDependenciesFail {
+				forbidden = append(forbidden, newDependencyEdge(module, externalDependency))
+				continue
+			}
+			if _, alreadyFound := allExternalDependencies[externalDependency.Path]; alreadyFound {
+				continue
+			}
+
 			alreadyApplied, err := confirmExternalDependencyAlreadyApplied(module, externalDependency, terragruntOptions)
 			if err != nil {
 				return externalDependencies, err
@@ -208,9 +288,22 @@ func resolveExternalDependenciesForModules(canonicalTerragruntConfigPaths []stri
 
 			externalDependency.AssumeAlreadyApplied = alreadyApplied
 			allExternalDependencies[externalDependency.Path] = externalDependency
+			if !alreadyApplied {
+				// The dependency is applied with the stack, so its own external dependencies must also be resolved
+				pending = append(pending, externalDependency)
+			}
 		}
 	}
 
+	if len(forbidden) > 0 {
+		sort.Slice(forbidden, func(i, j int) bool {
+			if forbidden[i].From != forbidden[j].From {
+				return forbidden[i].From < forbidden[j].From
+			}
+			return forbidden[i].To < forbidden[j].To
+		})
+		return allExternalDependencies, tgerrors.WithStackTrace(forbidden)
+	}
 	return allExternalDependencies, nil
 }
 
@@ -249,9 +342,18 @@ func resolveExternalDependenciesForModule(module *TerraformModule, canonicalTerr
 	return resolveModules(externalTerragruntConfigPaths, terragruntOptions, true)
 }
 
-// Confirm with the user whether they want Terragrunt to assume the given dependency of the given module is already
-// applied. If the user selects "no", then Terragrunt will apply that module as well.
+// Determine whether Terragrunt should assume the given dependency of the given module is already applied according to
+// the external dependencies policy. With the prompt policy, confirm with the user: if the user selects "no", then
+// Terragrunt will apply that module as well.
 func confirmExternalDependencyAlreadyApplied(module *TerraformModule, dependency *TerraformModule, terragruntOptions *options.TerragruntOptions) (bool, error) {
+	switch terragruntOptions.ExternalDependencies {
+	case ExternalDependenciesSkip:
+		terragruntOptions.Logger.Infof("Skipping external dependency %s of module %s", dependency.Path, module.Path)
+		return true, nil
+	case ExternalDependenciesInclude:
+		terragruntOptions.Logger.Infof("Including external dependency %s of module %s", dependency.Path, module.Path)
+		return false, nil
+	}
 	prompt := fmt.Sprintf("Module %s depends on module %s, which is an external dependency outside of the current working directory. "+
 		"Should Terragrunt skip over this external dependency? Warning, if you say 'no', Terragrunt will make changes in %s as well!",
 		module.Path, dependency.Path, dependency.Path)
@@ -356,3 +458,13 @@ type UnrecognizedDependency struct {
 func (err UnrecognizedDependency) Error() string {
 	return fmt.Sprintf("Module %s specifies %s as a dependency, but that dependency was not one of the ones found while scanning subfolders: %v", err.ModulePath, err.DependencyPath, err.TerragruntConfigPaths)
 }
+
+type errExternalDependencies []dependencyEdge
+
+func (err errExternalDependencies) Error() string {
+	lines := make([]string, len(err))
+	for i := range err {
+		lines[i] = "  " + err[i].String()
+	}
+	return fmt.Sprintf("Found %d external dependency(ies) while they are not allowed (--terragrunt-external-dependencies %s):\n%s", len(err), ExternalDependenciesFail, strings.Join(lines, "\n"))
+}
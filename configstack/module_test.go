@@ -1,11 +1,15 @@
 package configstack
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/stretchr/testify/assert"
 )
@@ -39,6 +43,58 @@ func TestResolveTerraformModulesOneModuleNoDependencies(t *testing.T) {
 	assertModuleListsEqual(t, expected, actualModules)
 }
 
+func TestResolveTerraformModulesCollectsParseErrors(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "parse-errors")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	var configPaths []string
+	for name, content := range map[string]string{"valid": "", "invalid-1": "inputs = {", "invalid-2": "unknown_attribute = 1"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(folder, name), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, name, "main.tf"), []byte(""), 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, name, config.DefaultConfigName), []byte(content), 0644))
+		configPaths = append(configPaths, filepath.Join(folder, name, config.DefaultConfigName))
+	}
+
+	// All modules are parsed and the error of each invalid module is reported
+	_, actualErr := ResolveTerraformModules(configPaths, mockOptions)
+	if assert.Error(t, actualErr) {
+		errs := tgerrors.Unwrap(actualErr).(errMulti).Errors
+		assert.Len(t, errs, 2)
+		assert.Contains(t, actualErr.Error(), filepath.Join(folder, "invalid-1"))
+		assert.Contains(t, actualErr.Error(), filepath.Join(folder, "invalid-2"))
+	}
+}
+
+func TestResolveTerraformModulesWithTemplates(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "templates")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	var configPaths []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("module-%d", i)
+		assert.NoError(t, os.MkdirAll(filepath.Join(folder, name), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, name, "main.tf"), []byte(""), 0644))
+		content := fmt.Sprintf(`inputs = { name = "@(upper("%s"))" }`, name)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, name, config.DefaultConfigName), []byte(content), 0644))
+		configPaths = append(configPaths, filepath.Join(folder, name, config.DefaultConfigName))
+	}
+
+	// The modules are resolved in parallel but gotemplate must process them one at a time (run with -race)
+	terragruntOptions := options.NewTerragruntOptionsForTest(filepath.Join(folder, config.DefaultConfigName))
+	terragruntOptions.ApplyTemplate = true
+	terragruntOptions.NbWorkers = 10
+	modules, err := ResolveTerraformModules(configPaths, terragruntOptions)
+	assert.NoError(t, err)
+	assert.Len(t, modules, 20)
+	for _, module := range modules {
+		assert.Equal(t, strings.ToUpper(filepath.Base(module.Path)), module.Config.Inputs["name"])
+	}
+}
+
 func TestResolveTerraformModulesOneModuleWithIncludesNoDependencies(t *testing.T) {
 	t.Parallel()
 
@@ -255,6 +311,51 @@ func TestResolveTerraformModulesMultipleModulesWithNestedExternalDependencies(t
 	assertModuleListsEqual(t, expected, actualModules)
 }
 
+func TestResolveTerraformModulesExternalDependenciesPolicy(t *testing.T) {
+	t.Parallel()
+
+	configPaths := []string{"../test/fixture-modules/module-j/" + config.DefaultConfigName, "../test/fixture-modules/module-k/" + config.DefaultConfigName}
+	moduleH, moduleI := canonical(t, "../test/fixture-modules/module-h"), canonical(t, "../test/fixture-modules/module-i")
+
+	tests := []struct {
+		policy         string
+		alreadyApplied map[string]bool
+		expectedErr    bool
+	}{
+		{ExternalDependenciesPrompt, map[string]bool{moduleH: true, moduleI: true}, false},
+		{ExternalDependenciesSkip, map[string]bool{moduleH: true, moduleI: true}, false},
+		{ExternalDependenciesInclude, map[string]bool{moduleH: false, moduleI: false}, false},
+		{ExternalDependenciesFail, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.policy, func(t *testing.T) {
+			t.Parallel()
+			terragruntOptions := mockOptions.Clone(mockOptions.TerragruntConfigPath)
+			terragruntOptions.ExternalDependencies = tt.policy
+
+			modules, err := ResolveTerraformModules(configPaths, terragruntOptions)
+			if tt.expectedErr {
+				assert.Equal(t, errExternalDependencies{
+					{canonical(t, "../test/fixture-modules/module-j"), moduleI, canonical(t, "../test/fixture-modules/module-j/"+config.DefaultConfigName), false},
+					{canonical(t, "../test/fixture-modules/module-k"), moduleH, canonical(t, "../test/fixture-modules/module-k/"+config.DefaultConfigName), false},
+				}, tgerrors.Unwrap(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, modules, 4)
+			for _, module := range modules {
+				assert.Equal(t, tt.alreadyApplied[module.Path], module.AssumeAlreadyApplied, module.Path)
+				if module.Path == moduleI {
+					if assert.Len(t, module.Dependencies, 1) {
+						assert.Equal(t, moduleH, module.Dependencies[0].Path)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestResolveTerraformModulesInvalidPaths(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,137 @@
+package configstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coveooss/terragrunt/v2/shell"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// PlanSummary is the summary of the resource changes of a saved plan (as reported by terraform show -json)
+type PlanSummary struct {
+	Create   int      `json:"create"`
+	Update   int      `json:"update"`
+	Replace  int      `json:"replace"`
+	Delete   int      `json:"delete"`
+	Replaced []string `json:"replaced,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+}
+
+// The subset of the terraform show -json format used to build the summary
+type jsonPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+func (summary PlanSummary) String() string {
+	if summary.Count() == 0 {
+		return "No change"
+	}
+	return fmt.Sprintf("%d to create, %d to update, %d to replace, %d to delete", summary.Create, summary.Update, summary.Replace, summary.Delete)
+}
+
+// Count returns the total number of resources affected by the plan
+func (summary PlanSummary) Count() int {
+	return summary.Create + summary.Update + summary.Replace + summary.Delete
+}
+
+// Returns the changes in the terraform plan format (the replaced resources are both added and destroyed)
+func (summary PlanSummary) planChanges() *PlanChanges {
+	return &PlanChanges{
+		Add:     summary.Create + summary.Replace,
+		Change:  summary.Update,
+		Destroy: summary.Delete + summary.Replace,
+	}
+}
+
+// Build the summary from the JSON representation of a plan
+func newPlanSummary(content []byte) (*PlanSummary, error) {
+	var plan jsonPlan
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+
+	summary := &PlanSummary{}
+	for _, resource := range plan.ResourceChanges {
+		actions := resource.Change.Actions
+		switch {
+		case util.ListContainsElement(actions, "delete") && util.ListContainsElement(actions, "create"):
+			summary.Replace++
+			summary.Replaced = append(summary.Replaced, resource.Address)
+		case util.ListContainsElement(actions, "create"):
+			summary.Create++
+		case util.ListContainsElement(actions, "update"):
+			summary.Update++
+		case util.ListContainsElement(actions, "delete"):
+			summary.Delete++
+			summary.Deleted = append(summary.Deleted, resource.Address)
+		}
+	}
+	sort.Strings(summary.Replaced)
+	sort.Strings(summary.Deleted)
+	return summary, nil
+}
+
+// Returns the name of the file where the plan of a module is saved. The name is derived from the relative path of the
+// module to be readable, but it also contains a hash of the path since distinct paths could have the same readable form
+// (i.e. a_b/c and a/b_c or ../x and x).
+func planFileName(key string) string {
+	key = filepath.ToSlash(key)
+	name := strings.Trim(strings.NewReplacer("/", "_", ":", "_").Replace(key), "._")
+	if name == "" {
+		name = "root"
+	}
+	return fmt.Sprintf("%s-%s.tfplan", name, util.EncodeBase64Sha1(key)[:12])
+}
+
+// Run terraform show -json on the saved plan of the module and summarize its resource changes
+func showPlan(module TerraformModule, planFile string) (*PlanSummary, error) {
+	// The module run context is done once the module is completed, so we must not use it to run the command
+	terragruntOptions := *module.TerragruntOptions
+	terragruntOptions.RunContext = nil
+
+	// The plan must be shown in the folder where it has been made (where terraform has been initialized)
+	if terragruntOptions.CommandWorkingDir != "" {
+		terragruntOptions.WorkingDir = terragruntOptions.CommandWorkingDir
+	}
+	output, err := shell.NewTFCmd(&terragruntOptions).Args("show", "-json", planFile).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v\n%s", err, output)
+	}
+	return newPlanSummary(firstJSONDocument(output))
+}
+
+// Returns the first valid JSON document of the output (other messages may be printed before or after it)
+func firstJSONDocument(output string) []byte {
+	for start := strings.Index(output, "{"); start >= 0; {
+		var document json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&document); err == nil {
+			return document
+		}
+		next := strings.Index(output[start+1:], "{")
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return []byte(output)
+}
+
+// Returns true if the user already saves the plan
+func hasPlanOutArgument(args []string) bool {
+	for _, arg := range args {
+		if arg == "-out" || strings.HasPrefix(arg, "-out=") {
+			return true
+		}
+	}
+	return false
+}
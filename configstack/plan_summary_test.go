@@ -0,0 +1,74 @@
+package configstack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPlanSummary(t *testing.T) {
+	t.Parallel()
+
+	const plan = `{
+		"format_version": "0.2",
+		"resource_changes": [
+			{"address": "aws_s3_bucket.new", "change": {"actions": ["create"]}},
+			{"address": "aws_s3_bucket.tags", "change": {"actions": ["update"]}},
+			{"address": "aws_instance.b", "change": {"actions": ["delete", "create"]}},
+			{"address": "aws_instance.a", "change": {"actions": ["create", "delete"]}},
+			{"address": "aws_iam_role.old", "change": {"actions": ["delete"]}},
+			{"address": "aws_iam_role.same", "change": {"actions": ["no-op"]}},
+			{"address": "data.aws_region.current", "change": {"actions": ["read"]}}
+		]
+	}`
+
+	summary, err := newPlanSummary([]byte(plan))
+	assert.NoError(t, err)
+	assert.Equal(t, &PlanSummary{
+		Create:   1,
+		Update:   1,
+		Replace:  2,
+		Delete:   1,
+		Replaced: []string{"aws_instance.a", "aws_instance.b"},
+		Deleted:  []string{"aws_iam_role.old"},
+	}, summary)
+	assert.Equal(t, 5, summary.Count())
+	assert.Equal(t, "1 to create, 1 to update, 2 to replace, 1 to delete", summary.String())
+
+	empty, err := newPlanSummary([]byte(`{"format_version": "0.2"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "No change", empty.String())
+
+	_, err = newPlanSummary([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestFirstJSONDocument(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"Only JSON", `{"format_version":"0.2"}`, `{"format_version":"0.2"}`},
+		{"Messages before", "Initializing {plugins}\n" + `{"format_version":"0.2"}`, `{"format_version":"0.2"}`},
+		{"Messages after", `{"format_version":"0.2"}` + "\nReleasing state lock. This may take a few moments...\n", `{"format_version":"0.2"}`},
+		{"No JSON", "not json", "not json"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, string(firstJSONDocument(tt.output)))
+		})
+	}
+}
+
+func TestHasPlanOutArgument(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, hasPlanOutArgument([]string{"plan", "-out=plan.tfplan"}))
+	assert.True(t, hasPlanOutArgument([]string{"plan", "-out", "plan.tfplan"}))
+	assert.False(t, hasPlanOutArgument([]string{"plan", "-detailed-exitcode"}))
+}
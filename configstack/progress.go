@@ -0,0 +1,281 @@
+package configstack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+const (
+	progressRefreshDelay = time.Second
+	defaultTerminalWidth = 120
+)
+
+// progressDisplay renders a live view of a -all command when the output is a terminal: a counter of the modules by
+// status followed by one line per running module (worker, module, phase, elapsed time and last output line). The
+// display is erased before the output of a finished module or a log is printed and drawn again after.
+//
+// The console logs of the modules are routed through the display (see logWriter) so they never overlap it.
+type progressDisplay struct {
+	writer    io.Writer
+	logWriter io.Writer  // The writer where the logs are actually printed
+	mutex     sync.Mutex // Protects the states and the terminal
+	states    map[*runningModule]*moduleProgress
+	width     int
+	lines     int  // The number of lines currently drawn
+	suspended int  // The number of outputs currently printed while the display is erased
+	stopped   bool // The display is not drawn anymore once stopped
+	done      chan bool
+}
+
+// moduleProgress is the state of a module as seen by the progress display
+type moduleProgress struct {
+	phase     string
+	startTime time.Time
+	outcome   string // Empty until the module is finished
+}
+
+// Create the progress display of the modules, returns nil if the output is not a terminal (in that case, the periodic
+// logs are used to show that the modules are still alive)
+func newProgressDisplay(modules map[string]*runningModule, writer io.Writer, logWriter io.Writer) *progressDisplay {
+	if !isTerminal(writer) {
+		return nil
+	}
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || width <= 0 {
+		width = defaultTerminalWidth
+	}
+	display := &progressDisplay{
+		writer:    writer,
+		logWriter: logWriter,
+		states:    make(map[*runningModule]*moduleProgress, len(modules)),
+		width:     width,
+		done:      make(chan bool),
+	}
+	for _, module := range modules {
+		display.states[module] = &moduleProgress{}
+	}
+	return display
+}
+
+// Returns true if the writer is a terminal
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := file.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Start refreshing the display periodically
+func (display *progressDisplay) start() {
+	if display == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(progressRefreshDelay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				display.mutex.Lock()
+				if display.suspended == 0 {
+					display.clear()
+					display.draw()
+				}
+				display.mutex.Unlock()
+			case <-display.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop refreshing the display and erase it
+func (display *progressDisplay) stop() {
+	if display == nil {
+		return
+	}
+	close(display.done)
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.clear()
+	display.stopped = true
+}
+
+// Erase the display until resume is called, the caller can then print its output
+func (display *progressDisplay) suspend() {
+	if display == nil {
+		return
+	}
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.clear()
+	display.suspended++
+}
+
+// Draw the display again once the output initiated by suspend has been printed
+func (display *progressDisplay) resume() {
+	if display == nil {
+		return
+	}
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	if display.suspended--; display.suspended == 0 {
+		display.draw()
+	}
+}
+
+// Returns the writer that must be used to print the logs of the modules while the display is active. The display is
+// erased before each log and drawn again after (unless an output is being printed or the display is stopped).
+func (display *progressDisplay) logs() io.Writer {
+	return progressLogWriter{display}
+}
+
+type progressLogWriter struct {
+	display *progressDisplay
+}
+
+func (writer progressLogWriter) Write(p []byte) (int, error) {
+	display := writer.display
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	if display.suspended > 0 {
+		// The display is already erased by the output being printed
+		return display.logWriter.Write(p)
+	}
+	display.clear()
+	defer display.draw()
+	return display.logWriter.Write(p)
+}
+
+// Record that the module is started
+func (display *progressDisplay) started(module *runningModule) {
+	if display == nil {
+		return
+	}
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.states[module].startTime = time.Now()
+}
+
+// Record the current phase of the module
+func (display *progressDisplay) setPhase(module *runningModule, phase string) {
+	if display == nil {
+		return
+	}
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.states[module].phase = phase
+}
+
+// Record that the module is finished (the shared mutex must be held since the status of the module is read)
+func (display *progressDisplay) finished(module *runningModule) {
+	if display == nil {
+		return
+	}
+	outcome := module.outcome()
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+	display.states[module].outcome = outcome
+}
+
+// Erase the lines previously drawn (the mutex of the display must be held)
+func (display *progressDisplay) clear() {
+	if display == nil || display.lines == 0 {
+		return
+	}
+	// Move the cursor up to the first line of the display and erase everything below
+	fmt.Fprintf(display.writer, "\033[%dA\033[J", display.lines)
+	display.lines = 0
+}
+
+// Draw the counters and the running modules (the mutex of the display must be held)
+func (display *progressDisplay) draw() {
+	if display == nil || display.stopped {
+		return
+	}
+	lines := []string{display.counters()}
+	var running []*runningModule
+	for module, state := range display.states {
+		if !state.startTime.IsZero() && state.outcome == "" {
+			running = append(running, module)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].workerID < running[j].workerID })
+	for _, module := range running {
+		lines = append(lines, display.moduleLine(module))
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(display.writer, truncate(line, display.width))
+	}
+	display.lines = len(lines)
+}
+
+// Returns the number of modules by status
+func (display *progressDisplay) counters() string {
+	var waiting, running, done, failed, skipped int
+	for _, state := range display.states {
+		switch {
+		case state.outcome == outcomeSucceeded:
+			done++
+		case state.outcome == outcomeFailed || state.outcome == outcomeTimedOut:
+			failed++
+		case state.outcome != "":
+			skipped++
+		case !state.startTime.IsZero():
+			running++
+		default:
+			waiting++
+		}
+	}
+	result := fmt.Sprintf("[waiting %d | running %d | done %d | failed %d", waiting, running, done, failed)
+	if skipped > 0 {
+		result += fmt.Sprintf(" | skipped %d", skipped)
+	}
+	return result + "]"
+}
+
+// Returns the description of a running module
+func (display *progressDisplay) moduleLine(module *runningModule) string {
+	state := display.states[module]
+	phase := state.phase
+	if phase == "" {
+		phase = "starting"
+	}
+	elapsed := time.Since(state.startTime).Round(time.Second)
+	return fmt.Sprintf("Worker #%0*d: %-40s %-12s %8v  %s",
+		len(strconv.Itoa(module.scheduler.nbWorkers())), module.workerID,
+		util.GetPathRelativeToWorkingDirMax(module.Module.Path, 3), phase, elapsed, lastLine(module.OutStream.String()))
+}
+
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+
+// Returns the last non empty line of the output (without color codes)
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(ansiEscapeRegex.ReplaceAllString(lines[i], "")); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Truncate the line to fit into the terminal (a wrapped line would break the erasure of the display)
+func truncate(line string, width int) string {
+	if runes := []rune(line); len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return line
+}
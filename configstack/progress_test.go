@@ -0,0 +1,94 @@
+package configstack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressDisplayNotTerminal(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newProgressDisplay(nil, &bytes.Buffer{}, &bytes.Buffer{}))
+
+	// All the methods are no-op on a nil display
+	var display *progressDisplay
+	display.start()
+	display.started(nil)
+	display.suspend()
+	display.resume()
+	display.draw()
+	display.stop()
+}
+
+func TestProgressDisplayDraw(t *testing.T) {
+	t.Parallel()
+
+	modules := createDurationsTestModules(t)
+	var output bytes.Buffer
+	display := &progressDisplay{writer: &output, states: map[*runningModule]*moduleProgress{}, width: 200}
+	for _, module := range modules {
+		display.states[module] = &moduleProgress{}
+		module.scheduler = newScheduler(context.Background(), 2, 0)
+	}
+
+	modules["a"].workerID = 1
+	display.started(modules["a"])
+	display.setPhase(modules["a"], options.PhaseInit)
+	fmt.Fprint(&modules["a"].OutStream, "\033[1mInitializing provider plugins...\033[0m\n\n")
+	modules["d"].workerID = 2
+	display.started(modules["d"])
+	modules["d"].Err = fmt.Errorf("error")
+	display.finished(modules["d"])
+
+	display.draw()
+	assert.Equal(t, 2, display.lines)
+	assert.Regexp(t, `^\[waiting 2 \| running 1 \| done 0 \| failed 1\]\nWorker #1: a +init +0s  Initializing provider plugins\.\.\.\n$`, output.String())
+
+	output.Reset()
+	display.clear()
+	assert.Equal(t, "\033[2A\033[J", output.String())
+	assert.Equal(t, 0, display.lines)
+}
+
+func TestProgressDisplayLogs(t *testing.T) {
+	t.Parallel()
+
+	// The display and the logs share the same terminal
+	var output bytes.Buffer
+	display := &progressDisplay{writer: &output, logWriter: &output, states: map[*runningModule]*moduleProgress{}, width: 200}
+	display.states[&runningModule{}] = &moduleProgress{}
+	display.draw()
+
+	// The display is erased before the log and drawn again after
+	output.Reset()
+	fmt.Fprintln(display.logs(), "warning")
+	assert.Equal(t, "\033[1A\033[Jwarning\n[waiting 1 | running 0 | done 0 | failed 0]\n", output.String())
+
+	// The display is not drawn while an output is printed
+	output.Reset()
+	display.suspend()
+	fmt.Fprintln(display.logs(), "warning")
+	assert.Equal(t, "\033[1A\033[Jwarning\n", output.String())
+	output.Reset()
+	display.resume()
+	assert.Equal(t, "[waiting 1 | running 0 | done 0 | failed 0]\n", output.String())
+
+	// Once stopped, the logs are printed as is
+	display.done = make(chan bool)
+	output.Reset()
+	display.stop()
+	fmt.Fprintln(display.logs(), "warning")
+	assert.Equal(t, "\033[1A\033[Jwarning\n", output.String())
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "too long…", truncate("too long line", 9))
+}
@@ -0,0 +1,282 @@
+package configstack
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Supported report formats
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatJUnit = "junit"
+)
+
+// The status of the modules that already succeeded in the run being resumed (they are not executed again)
+const reportStatusResumed = "resumed"
+
+// StackReport is the machine-readable report generated at the end of a -all command
+type StackReport struct {
+	RunID     string         `json:"run_id"`
+	Command   string         `json:"command"`
+	StartTime time.Time      `json:"start_time"`
+	EndTime   time.Time      `json:"end_time"`
+	Duration  float64        `json:"duration_seconds"`
+	Summary   map[string]int `json:"summary"`
+	Modules   []ModuleReport `json:"modules"`
+}
+
+// ModuleReport is the report of the execution of a single module
+type ModuleReport struct {
+	Path          string       `json:"path"`
+	Status        string       `json:"status"`
+	ExitCode      int          `json:"exit_code"`
+	Error         string       `json:"error,omitempty"`
+	SkippedReason string       `json:"skipped_reason,omitempty"`
+	Changes       *PlanChanges `json:"changes,omitempty"`
+	Attempts      int          `json:"attempts,omitempty"`
+	StartTime     *time.Time   `json:"start_time,omitempty"`
+	EndTime       *time.Time   `json:"end_time,omitempty"`
+	Duration      float64      `json:"duration_seconds"`
+}
+
+// PlanChanges represents the number of changes reported by terraform plan
+type PlanChanges struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// Build the report from the running modules
+func newStackReport(modules map[string]*runningModule, startTime time.Time) *StackReport {
+	report := &StackReport{
+		StartTime: startTime,
+		EndTime:   time.Now(),
+		Summary:   map[string]int{},
+		Modules:   make([]ModuleReport, 0, len(modules)),
+	}
+	report.Duration = report.EndTime.Sub(report.StartTime).Seconds()
+
+	for _, module := range modules {
+		terragruntOptions := module.Module.TerragruntOptions
+		command := util.IndexOrDefault(terragruntOptions.TerraformCliArgs, 0, "")
+		report.RunID = terragruntOptions.Env[options.EnvRunID]
+		report.Command = command
+
+		moduleReport := ModuleReport{
+			Path:     util.GetPathRelativeToWorkingDir(module.Module.Path),
+			Status:   module.outcome(),
+			ExitCode: module.exitCode(),
+			Duration: module.duration().Seconds(),
+			Attempts: module.attempts,
+		}
+		if module.resumed {
+			moduleReport.Status = reportStatusResumed
+			moduleReport.SkippedReason = "Already succeeded in the resumed run"
+		}
+		if !module.startTime.IsZero() {
+			moduleReport.StartTime, moduleReport.EndTime = &module.startTime, &module.endTime
+		}
+		if module.Err != nil {
+			moduleReport.Error = module.Err.Error()
+			if dependencyErr, ok := tgerrors.Unwrap(module.Err).(dependencyFinishedWithError); ok {
+				moduleReport.SkippedReason = fmt.Sprintf("Dependency %s finished with an error", util.GetPathRelativeToWorkingDir(dependencyErr.Dependency.Path))
+			}
+		}
+		if command == "plan" && !module.resumed {
+			if summary := module.Module.planSummary; summary != nil {
+				moduleReport.Changes = summary.planChanges()
+			} else {
+				// The plan has not been saved (i.e. -out specified by the user), the changes are read from the output
+				moduleReport.Changes = extractPlanChanges(module.OutStream.String())
+			}
+		}
+		report.Summary[moduleReport.Status]++
+		report.Modules = append(report.Modules, moduleReport)
+	}
+
+	sort.Slice(report.Modules, func(i, j int) bool { return report.Modules[i].Path < report.Modules[j].Path })
+	return report
+}
+
+// Extract the number of changes from the output of terraform plan (nil if the plan status cannot be determined)
+func extractPlanChanges(output string) *PlanChanges {
+	if _, count := extractSummaryResultFromPlan(output); count == 0 {
+		return &PlanChanges{}
+	}
+	result := planResultRegex.FindStringSubmatch(output)
+	if len(result) == 0 {
+		return nil
+	}
+	changes := &PlanChanges{}
+	changes.Add, _ = strconv.Atoi(result[1])
+	changes.Change, _ = strconv.Atoi(result[2])
+	changes.Destroy, _ = strconv.Atoi(result[3])
+	return changes
+}
+
+// Save writes the report to the specified file. If the format is not specified, it is determined by the file extension.
+func (report *StackReport) Save(filename, format string) error {
+	if format == "" {
+		format = ReportFormatJSON
+		if strings.EqualFold(filepath.Ext(filename), ".xml") {
+			format = ReportFormatJUnit
+		}
+	}
+
+	var content []byte
+	var err error
+	switch format {
+	case ReportFormatJSON:
+		content, err = json.MarshalIndent(report, "", "  ")
+	case ReportFormatJUnit:
+		content, err = xml.MarshalIndent(report.junit(), "", "  ")
+		content = append([]byte(xml.Header), content...)
+	default:
+		return tgerrors.WithStackTrace(errUnsupportedReportFormat(format))
+	}
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+
+	if folder := filepath.Dir(filename); !util.FileExists(folder) {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return tgerrors.WithStackTrace(err)
+		}
+	}
+	return tgerrors.WithStackTrace(ioutil.WriteFile(filename, append(content, '\n'), 0644))
+}
+
+// Write the report if the user asked for it
+func saveReport(modules map[string]*runningModule, startTime time.Time, terragruntOptions *options.TerragruntOptions) {
+	if terragruntOptions.ReportFile == "" {
+		return
+	}
+	if err := newStackReport(modules, startTime).Save(terragruntOptions.ReportFile, terragruntOptions.ReportFormat); err != nil {
+		terragruntOptions.Logger.Errorf("Unable to write the report %s: %v", terragruntOptions.ReportFile, err)
+		return
+	}
+	terragruntOptions.Logger.Infof("Report saved to %s", terragruntOptions.ReportFile)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	ID         string          `xml:"id,attr,omitempty"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// Convert the report into the JUnit XML format
+func (report *StackReport) junit() junitTestSuites {
+	seconds := func(value float64) string { return strconv.FormatFloat(value, 'f', 3, 64) }
+	name := fmt.Sprintf("terragrunt %s-all", report.Command)
+	suite := junitTestSuite{
+		Name:      name,
+		ID:        report.RunID,
+		Tests:     len(report.Modules),
+		Time:      seconds(report.Duration),
+		Timestamp: report.StartTime.Format(time.RFC3339),
+		Properties: []junitProperty{
+			{Name: "run_id", Value: report.RunID},
+			{Name: "command", Value: report.Command},
+		},
+	}
+
+	for _, module := range report.Modules {
+		testCase := junitTestCase{
+			Name:      module.Path,
+			ClassName: fmt.Sprintf("terragrunt.%s", report.Command),
+			Time:      seconds(module.Duration),
+		}
+		if module.Changes != nil {
+			testCase.SystemOut = fmt.Sprintf("%d to add, %d to change, %d to destroy", module.Changes.Add, module.Changes.Change, module.Changes.Destroy)
+		}
+		switch module.Status {
+		case outcomeSkipped, reportStatusResumed:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: module.SkippedReason, Content: module.Error}
+		case outcomeCancelled:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: firstLine(module.Error)}
+		case outcomeFailed, outcomeTimedOut:
+			failureType := fmt.Sprintf("exit code %d", module.ExitCode)
+			if module.Status == outcomeTimedOut {
+				failureType = "timeout"
+			}
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: firstLine(module.Error),
+				Type:    failureType,
+				Content: module.Error,
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	return junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+}
+
+// Custom error types
+
+type errUnsupportedReportFormat string
+
+func (err errUnsupportedReportFormat) Error() string {
+	return fmt.Sprintf("Unsupported report format %s (should be %s or %s)", string(err), ReportFormatJSON, ReportFormatJUnit)
+}
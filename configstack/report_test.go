@@ -0,0 +1,159 @@
+package configstack
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func createReportTestModules(t *testing.T, reportFile string) []*TerraformModule {
+	setOptions := func(opts *options.TerragruntOptions) *options.TerragruntOptions {
+		opts.TerraformCliArgs = []string{"apply"}
+		opts.Env[options.EnvRunID] = "report-test"
+		opts.ReportFile = reportFile
+		return opts
+	}
+
+	var aRan, bRan, cRan bool
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("a", nil, &aRan)),
+	}
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{moduleA},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("b", fmt.Errorf("Expected error for module b"), &bRan)),
+	}
+	// Simulate a module that has been retried on transient errors
+	runB := moduleB.TerragruntOptions.RunTerragrunt
+	moduleB.TerragruntOptions.RunTerragrunt = func(opts *options.TerragruntOptions) error {
+		opts.Attempts = 3
+		return runB(opts)
+	}
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{moduleB},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: setOptions(optionsWithMockTerragruntCommand("c", nil, &cRan)),
+	}
+	return []*TerraformModule{moduleA, moduleB, moduleC}
+}
+
+func TestRunModulesWithJSONReport(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "report")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	reportFile := filepath.Join(folder, "report.json")
+
+	assert.Error(t, runModules(createReportTestModules(t, reportFile)))
+
+	content, err := ioutil.ReadFile(reportFile)
+	assert.NoError(t, err)
+	var report StackReport
+	assert.NoError(t, json.Unmarshal(content, &report))
+
+	assert.Equal(t, "report-test", report.RunID)
+	assert.Equal(t, "apply", report.Command)
+	assert.Equal(t, map[string]int{outcomeSucceeded: 1, outcomeFailed: 1, outcomeSkipped: 1}, report.Summary)
+	if assert.Len(t, report.Modules, 3) {
+		assert.Equal(t, "a", report.Modules[0].Path)
+		assert.Equal(t, outcomeSucceeded, report.Modules[0].Status)
+		assert.NotNil(t, report.Modules[0].StartTime)
+		assert.Equal(t, outcomeFailed, report.Modules[1].Status)
+		assert.Equal(t, "Expected error for module b", report.Modules[1].Error)
+		assert.Equal(t, errorExitCode, report.Modules[1].ExitCode)
+		assert.Equal(t, 3, report.Modules[1].Attempts)
+		assert.Equal(t, outcomeSkipped, report.Modules[2].Status)
+		assert.Equal(t, "Dependency b finished with an error", report.Modules[2].SkippedReason)
+		assert.Nil(t, report.Modules[2].StartTime)
+	}
+}
+
+func TestRunModulesWithJUnitReport(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "report")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	reportFile := filepath.Join(folder, "report.xml")
+
+	assert.Error(t, runModules(createReportTestModules(t, reportFile)))
+
+	content, err := ioutil.ReadFile(reportFile)
+	assert.NoError(t, err)
+	var report junitTestSuites
+	assert.NoError(t, xml.Unmarshal(content, &report))
+
+	assert.Equal(t, 3, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, 1, report.Skipped)
+	if assert.Len(t, report.Suites, 1) && assert.Len(t, report.Suites[0].TestCases, 3) {
+		testCases := report.Suites[0].TestCases
+		assert.Nil(t, testCases[0].Failure)
+		assert.Equal(t, "exit code 1", testCases[1].Failure.Type)
+		assert.Equal(t, "Dependency b finished with an error", testCases[2].Skipped.Message)
+	}
+}
+
+func TestStackReportPlanChangesAndResumedModules(t *testing.T) {
+	t.Parallel()
+
+	var ran bool
+	newModule := func(path string) *TerraformModule {
+		module := &TerraformModule{Path: path, TerragruntOptions: optionsWithMockTerragruntCommand(path, nil, &ran)}
+		module.TerragruntOptions.TerraformCliArgs = []string{"plan"}
+		return module
+	}
+	saved, notSaved, resumed := newModule("saved"), newModule("not-saved"), newModule("resumed")
+	saved.planSummary = &PlanSummary{Create: 1, Update: 2, Replace: 1}
+	modules, err := toRunningModules([]*TerraformModule{saved, notSaved, resumed}, NormalOrder)
+	assert.NoError(t, err)
+	modules["saved"].OutStream.WriteString("Terraform 1.x output that is not parsed")
+	modules["not-saved"].OutStream.WriteString("Plan: 1 to add, 0 to change, 0 to destroy.")
+	modules["resumed"].resumed = true
+
+	report := newStackReport(modules, time.Now())
+	assert.Equal(t, map[string]int{outcomeSucceeded: 2, reportStatusResumed: 1}, report.Summary)
+	if assert.Len(t, report.Modules, 3) {
+		assert.Equal(t, &PlanChanges{1, 0, 0}, report.Modules[0].Changes, "The changes are read from the output if the plan has not been saved")
+		assert.Equal(t, reportStatusResumed, report.Modules[1].Status)
+		assert.Nil(t, report.Modules[1].Changes)
+		assert.Equal(t, &PlanChanges{2, 2, 1}, report.Modules[2].Changes, "The changes are read from the saved plan")
+	}
+
+	junit := report.junit()
+	assert.Equal(t, 1, junit.Skipped, "The resumed modules are reported as skipped")
+}
+
+func TestExtractPlanChanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		output   string
+		expected *PlanChanges
+	}{
+		{"Plan: 1 to add, 2 to change, 3 to destroy.", &PlanChanges{1, 2, 3}},
+		{"No changes. Infrastructure is up-to-date.", &PlanChanges{}},
+		{"No changes. Your infrastructure matches the configuration.", &PlanChanges{}},
+		{"Error: something went wrong", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extractPlanChanges(tt.output))
+		})
+	}
+}
@@ -1,36 +1,230 @@
 package configstack
 
 import (
+	"container/heap"
+	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/coveooss/terragrunt/v2/util"
 	"github.com/fatih/color"
 )
 
-const waitTimeBetweenThread = 2500
+// scheduler limits the number of modules that run concurrently in a -all command. Each run has its own scheduler and
+// the workers are made available progressively (one every rampUp delay) to avoid throttling when many modules are
+// started at the same time. When modules are waiting for a worker, the free worker is given to the module with the
+// highest priority (or to the first one that started waiting if they have the same priority).
+type scheduler struct {
+	mutex     sync.Mutex
+	size      int
+	available []int          // The workers that are not currently used
+	waiting   workerRequests // The modules waiting for a worker
+	sequence  int            // Used to preserve the arrival order of the modules having the same priority
+}
+
+// Create a scheduler with the specified number of workers. The first worker is available immediately and the
+// remaining ones are added in background until the context is done.
+func newScheduler(ctx context.Context, nbWorkers int, rampUp time.Duration) *scheduler {
+	if nbWorkers <= 0 {
+		panic(fmt.Errorf("the number of workers must be greater than 0 (%d)", nbWorkers))
+	}
+	s := &scheduler{size: nbWorkers, available: []int{1}}
+	if rampUp <= 0 {
+		for i := 2; i <= nbWorkers; i++ {
+			s.available = append(s.available, i)
+		}
+		return s
+	}
+
+	go func() {
+		for i := 2; i <= nbWorkers; i++ {
+			select {
+			case <-time.After(rampUp):
+				s.freeWorker(i)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Returns the number of workers of the scheduler (1 if there is no scheduler)
+func (s *scheduler) nbWorkers() int {
+	if s == nil {
+		return 1
+	}
+	return s.size
+}
+
+// Give the worker to the waiting module with the highest priority or make it available if no module is waiting
+func (s *scheduler) freeWorker(token int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.waiting) > 0 {
+		heap.Pop(&s.waiting).(*workerRequest).granted <- token
+		return
+	}
+	s.available = append(s.available, token)
+}
+
+// Wait for a worker to be available, returns false if the wait has been cancelled
+func (s *scheduler) waitWorker(priority time.Duration, cancel <-chan struct{}) (int, bool) {
+	select {
+	case <-cancel:
+		return 0, false
+	default:
+	}
 
-func initWorkers(n int) {
-	if n <= 0 {
-		panic(fmt.Errorf("the number of workers must be greater than 0 (%d)", n))
+	s.mutex.Lock()
+	if len(s.available) > 0 {
+		token := s.available[0]
+		s.available = s.available[1:]
+		s.mutex.Unlock()
+		return token, true
 	}
-	if burstyLimiter == nil {
-		burstyLimiter = make(chan int, n)
-		for i := 1; i <= n; i++ {
-			time.Sleep(waitTimeBetweenThread * time.Millisecond) // Start workers progressively to avoid throttling
-			burstyLimiter <- i
+	request := &workerRequest{priority: priority, sequence: s.sequence, granted: make(chan int, 1)}
+	s.sequence++
+	heap.Push(&s.waiting, request)
+	s.mutex.Unlock()
+
+	select {
+	case token := <-request.granted:
+		select {
+		case <-cancel:
+			// The cancellation has precedence over the worker availability
+			s.freeWorker(token)
+			return 0, false
+		default:
+			return token, true
+		}
+	case <-cancel:
+		s.mutex.Lock()
+		if request.index >= 0 {
+			heap.Remove(&s.waiting, request.index)
+			s.mutex.Unlock()
+			return 0, false
 		}
+		s.mutex.Unlock()
+		// The worker has been granted while the wait was cancelled
+		s.freeWorker(<-request.granted)
+		return 0, false
 	}
 }
 
-func nbWorkers() int       { return cap(burstyLimiter) }
-func waitWorker() int      { return <-burstyLimiter }
-func freeWorker(token int) { burstyLimiter <- token }
+// workerRequest represents a module waiting for a worker
+type workerRequest struct {
+	priority time.Duration
+	sequence int
+	index    int // The position of the request in the heap (-1 once the request is removed from the heap)
+	granted  chan int
+}
 
-var burstyLimiter chan int
+// workerRequests is a priority queue (heap.Interface) of the modules waiting for a worker
+type workerRequests []*workerRequest
+
+func (requests workerRequests) Len() int { return len(requests) }
+
+func (requests workerRequests) Less(i, j int) bool {
+	if requests[i].priority != requests[j].priority {
+		return requests[i].priority > requests[j].priority
+	}
+	return requests[i].sequence < requests[j].sequence
+}
+
+func (requests workerRequests) Swap(i, j int) {
+	requests[i], requests[j] = requests[j], requests[i]
+	requests[i].index, requests[j].index = i, j
+}
+
+func (requests *workerRequests) Push(x interface{}) {
+	request := x.(*workerRequest)
+	request.index = len(*requests)
+	*requests = append(*requests, request)
+}
+
+func (requests *workerRequests) Pop() interface{} {
+	old := *requests
+	request := old[len(old)-1]
+	old[len(old)-1] = nil
+	request.index = -1
+	*requests = old[:len(old)-1]
+	return request
+}
+
+// concurrencyGroups limits the number of modules of the same group that can run concurrently in a -all command
+type concurrencyGroups map[string]chan bool
+
+// Create the limiters of all the concurrency groups defined by the modules. If the modules do not agree on the maximum
+// of a group, the lowest one is used.
+func newConcurrencyGroups(modules map[string]*runningModule) concurrencyGroups {
+	limits := map[string]int{}
+	for _, module := range modules {
+		for _, group := range module.Module.Config.ConcurrencyGroups {
+			if current, found := limits[group.Name]; !found || group.Max < current {
+				limits[group.Name] = group.Max
+			}
+		}
+	}
+
+	groups := make(concurrencyGroups, len(limits))
+	for name, limit := range limits {
+		groups[name] = make(chan bool, limit)
+	}
+	return groups
+}
+
+// Returns the sorted names of the concurrency groups of the module. Slots are always acquired in the same order to
+// avoid deadlocks between modules sharing more than one group.
+func (module *runningModule) concurrencyGroupNames() []string {
+	names := make([]string, 0, len(module.Module.Config.ConcurrencyGroups))
+	for _, group := range module.Module.Config.ConcurrencyGroups {
+		if !util.ListContainsElement(names, group.Name) {
+			names = append(names, group.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait for a slot in each concurrency group of the module, returns false if the wait has been cancelled (in that case,
+// the slots already acquired are released)
+func (module *runningModule) acquireConcurrencyGroups(cancel <-chan struct{}) bool {
+	names := module.concurrencyGroupNames()
+	for i, name := range names {
+		select {
+		case module.groups[name] <- true:
+			continue
+		default:
+		}
+
+		module.Module.TerragruntOptions.Logger.Debugf("Module %s is waiting for a slot in concurrency group %s", util.GetPathRelativeToWorkingDirMax(module.Module.Path, 3), name)
+		select {
+		case module.groups[name] <- true:
+		case <-cancel:
+			module.releaseConcurrencyGroups(names[:i]...)
+			return false
+		}
+	}
+	return true
+}
+
+// Release the slots of the specified concurrency groups (all groups of the module if not specified)
+func (module *runningModule) releaseConcurrencyGroups(names ...string) {
+	if names == nil {
+		names = module.concurrencyGroupNames()
+	}
+	for _, name := range names {
+		<-module.groups[name]
+	}
+}
 
 // OutputPeriodicLogs displays current module output for long running request
 func (module *runningModule) OutputPeriodicLogs(completed *bool) {
-	if module.Module.TerragruntOptions.RefreshOutputDelay == 0 {
+	if module.Module.TerragruntOptions.RefreshOutputDelay == 0 || module.progress != nil {
+		// The output is not logged periodically when the progress is displayed live
 		return
 	}
 	writer := module.Module.TerragruntOptions.Logger.Infof
@@ -2,12 +2,17 @@ package configstack
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"math"
+	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/shell"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/coveooss/terragrunt/v2/util"
@@ -27,6 +32,7 @@ const (
 	waiting ModuleStatus = iota
 	running
 	finished
+	cancelled
 )
 
 // CreateMultiErrors declared as a variable instead of a function allows us to override the function used to compose multi error object.
@@ -50,10 +56,22 @@ type runningModule struct {
 
 	bufferIndex int // Indicates the position of the buffer that has been flushed to the logger
 	workerID    int
+	journal     *runJournal        // The journal used to record the outcome of the module
+	scheduler   *scheduler         // The scheduler that limits the number of concurrent modules of the run
+	priority    time.Duration      // The estimated length of the chain of modules waiting for this module
+	progress    *progressDisplay   // The live display of the run (nil if the output is not a terminal)
+	groups      concurrencyGroups  // The limiters of the concurrency groups of the run
+	runCtx      context.Context    // Expires when the whole execution times out
+	ctx         context.Context    // Done when the whole execution is cancelled (i.e. on fail fast or timeout)
+	cancel      context.CancelFunc // Used to cancel the whole execution (i.e. on fail fast)
+	resumed     bool               // Indicates that the module already succeeded in the run being resumed
+	attempts    int                // The number of times the terraform command has been executed
+	startTime   time.Time
+	endTime     time.Time
 }
 
 func (module runningModule) displayName() string {
-	format := int(math.Log10(math.Min(float64(nbWorkers()), 1)) + 1)
+	format := int(math.Log10(float64(module.scheduler.nbWorkers())) + 1)
 	return fmt.Sprintf("Worker #%0*d: %s", format, module.workerID, util.GetPathRelativeToWorkingDirMax(module.Module.Path, 3))
 }
 
@@ -113,17 +131,71 @@ func runModulesWithHandler(modules []*TerraformModule, handler ModuleHandler, or
 		return err
 	}
 
+	if len(runningModules) == 0 {
+		return nil
+	}
+
+	// All modules share the same global options, so we use the options of any module to configure the run
+	var terragruntOptions *options.TerragruntOptions
 	for _, module := range runningModules {
-		// Starts mechanism that control the maximum number of active workers
-		if module.Module.TerragruntOptions.NbWorkers <= 0 {
-			module.Module.TerragruntOptions.NbWorkers = len(runningModules)
-		}
-		initWorkers(module.Module.TerragruntOptions.NbWorkers)
+		terragruntOptions = module.Module.TerragruntOptions
 		break
 	}
 
-	var waitGroup sync.WaitGroup
+	if terragruntOptions.NbWorkers <= 0 {
+		terragruntOptions.NbWorkers = len(runningModules)
+	}
+
+	journal, err := openJournal(terragruntOptions)
+	if err != nil {
+		return err
+	}
+
+	// The run context expires when the run timeout is reached, it interrupts the running modules. The cancel context is
+	// derived from it and is also cancelled on fail fast, it prevents the pending modules from being started.
+	runCtx, cancelRun := context.Background(), context.CancelFunc(func() {})
+	if terragruntOptions.RunTimeout > 0 {
+		runCtx, cancelRun = context.WithTimeout(runCtx, terragruntOptions.RunTimeout)
+	}
+	defer cancelRun()
+	ctx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+
+	// Starts mechanism that control the maximum number of active workers (there is no need for more workers than modules)
+	nbWorkers := terragruntOptions.NbWorkers
+	if nbWorkers > len(runningModules) {
+		nbWorkers = len(runningModules)
+	}
+	scheduler := newScheduler(ctx, nbWorkers, terragruntOptions.WorkersRampUp)
+	groups := newConcurrencyGroups(runningModules)
+	progress := newProgressDisplay(runningModules, os.Stdout, os.Stderr)
 	for _, module := range runningModules {
+		module.journal = journal
+		module.scheduler = scheduler
+		module.progress = progress
+		if progress != nil {
+			module := module
+			module.Module.TerragruntOptions.PhaseListener = func(phase string) { progress.setPhase(module, phase) }
+			// The logs are printed through the display to avoid overlapping it
+			module.Module.TerragruntOptions.Logger.SetOut(progress.logs())
+		}
+		module.groups = groups
+		module.resumed = journal.alreadySucceeded(module.Module)
+		module.runCtx = runCtx
+		module.ctx, module.cancel = ctx, cancel
+	}
+
+	// The modules are prioritized by the length of the chain of modules waiting for them unless FIFO is requested
+	durations := loadDurations(terragruntOptions)
+	if terragruntOptions.SchedulePolicy != ScheduleFIFO {
+		setCriticalPathPriorities(runningModules, durations)
+	}
+
+	startTime := time.Now()
+
+	progress.start()
+	var waitGroup sync.WaitGroup
+	for _, module := range sortByPriority(runningModules) {
 		waitGroup.Add(1)
 		module.Handler = handler
 		go func(module *runningModule) {
@@ -141,8 +213,33 @@ func runModulesWithHandler(modules []*TerraformModule, handler ModuleHandler, or
 	}
 
 	waitGroup.Wait()
+	progress.stop()
+
+	if err := durations.save(runningModules); err != nil {
+		terragruntOptions.Logger.Warningf("Unable to save the durations of the modules in %s: %v", DurationsFile(), err)
+	}
+	saveReport(runningModules, startTime, terragruntOptions)
+	logRetriedModules(runningModules, terragruntOptions)
 
-	return collectErrors(runningModules)
+	err = collectErrors(runningModules)
+	if err != nil && journal != nil {
+		terragruntOptions.Logger.Infof("The outcome of each module has been saved in %s. Use --terragrunt-resume %s to skip the modules that already succeeded.", journal.path, journal.id)
+	}
+	return err
+}
+
+// Summarize the modules that have been retried because of transient errors
+func logRetriedModules(modules map[string]*runningModule, terragruntOptions *options.TerragruntOptions) {
+	var retried []string
+	for _, module := range modules {
+		if module.attempts > 1 {
+			retried = append(retried, fmt.Sprintf("%s (%d attempts, %s)", util.GetPathRelativeToWorkingDir(module.Module.Path), module.attempts, module.outcome()))
+		}
+	}
+	if len(retried) > 0 {
+		sort.Strings(retried)
+		terragruntOptions.Logger.Warningf("%d module(s) retried on transient errors:\n  %s", len(retried), strings.Join(retried, "\n  "))
+	}
 }
 
 // Convert the list of modules to a map from module path to a runningModule struct. This struct contains information
@@ -213,9 +310,19 @@ func (module *runningModule) dependencies() []string {
 func (module *runningModule) runModuleWhenReady() {
 	err := module.waitForDependencies()
 	if err == nil {
-		module.workerID = waitWorker()
-		defer func() { freeWorker(module.workerID) }()
-		err = module.runNow()
+		// The slots of the concurrency groups are acquired before the worker to avoid holding a worker while waiting
+		if module.acquireConcurrencyGroups(module.ctx.Done()) {
+			defer module.releaseConcurrencyGroups()
+			var acquired bool
+			if module.workerID, acquired = module.scheduler.waitWorker(module.priority, module.ctx.Done()); acquired {
+				defer func() { module.scheduler.freeWorker(module.workerID) }()
+				err = module.runNow()
+			} else {
+				err = errModuleCancelled{module.Module, nil}
+			}
+		} else {
+			err = errModuleCancelled{module.Module, nil}
+		}
 	}
 	module.moduleFinished(err)
 }
@@ -228,12 +335,25 @@ func (module *runningModule) waitForDependencies() error {
 		log.Debugf("Module %s must wait for %s to finish", module.displayName(), strings.Join(module.dependencies(), ", "))
 	}
 	for len(module.Dependencies) > 0 {
-		doneDependency := <-module.DependencyDone
+		var doneDependency *runningModule
+		select {
+		case doneDependency = <-module.DependencyDone:
+		case <-module.ctx.Done():
+			// The result of an already completed dependency has precedence over the cancellation
+			select {
+			case doneDependency = <-module.DependencyDone:
+			default:
+				return errModuleCancelled{module.Module, nil}
+			}
+		}
 		delete(module.Dependencies, doneDependency.Module.Path)
 
 		depPath := util.GetPathRelativeToWorkingDirMax(doneDependency.Module.Path, 3)
 
-		if doneDependency.Err != nil {
+		if doneDependency.Status == cancelled {
+			log.Debugf("Dependency %s of module %s has been cancelled, so the module is cancelled too", depPath, module.displayName())
+			return errModuleCancelled{module.Module, nil}
+		} else if doneDependency.Err != nil {
 			if module.Module.TerragruntOptions.IgnoreDependencyErrors {
 				log.Warningf("Dependency %[1]s of module %[2]s just finished with an error. Module %[2]s will have to return an error too. However, because of --terragrunt-ignore-dependency-errors, module %[2]s will run anyway.", depPath, module.displayName())
 			} else {
@@ -255,13 +375,87 @@ func (module *runningModule) waitForDependencies() error {
 // Run a module right now by executing the RunTerragrunt command of its TerragruntOptions field.
 func (module *runningModule) runNow() error {
 	module.Status = running
+	module.startTime = time.Now()
+	module.progress.started(module)
 
+	if module.resumed {
+		module.Module.TerragruntOptions.Logger.Infof("Module %s already succeeded in the resumed run, skipping it", module.displayName())
+		return nil
+	}
 	if module.Module.AssumeAlreadyApplied {
 		module.Module.TerragruntOptions.Logger.Debugf("Assuming module %s has already been applied and skipping it", module.displayName())
 		return nil
 	}
-	module.Module.TerragruntOptions.Logger.Debugf("Running module %s now", module.displayName())
-	return module.Module.TerragruntOptions.RunTerragrunt(module.Module.TerragruntOptions)
+
+	terragruntOptions := module.Module.TerragruntOptions
+	timeout, err := module.Module.Config.GetTimeout(terragruntOptions.ModuleTimeout)
+	if err != nil {
+		return err
+	}
+
+	// The running commands are interrupted if the whole execution times out, if the module times out or if the
+	// execution is cancelled by the fail fast interrupt mode
+	runCtx := module.runCtx
+	if terragruntOptions.FailFastInterrupt {
+		runCtx = module.ctx
+	}
+	moduleCtx, cancelModule := runCtx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		moduleCtx, cancelModule = context.WithTimeout(runCtx, timeout)
+	}
+	defer cancelModule()
+	terragruntOptions.RunContext = moduleCtx
+
+	terragruntOptions.Logger.Debugf("Running module %s now", module.displayName())
+	terragruntOptions.Attempts = 0
+	err = terragruntOptions.RunTerragrunt(terragruntOptions)
+	module.attempts = terragruntOptions.Attempts
+	if err == nil || moduleCtx.Err() == nil {
+		return err
+	}
+
+	// The module has been interrupted
+	switch {
+	case module.runCtx.Err() == context.DeadlineExceeded:
+		return errModuleTimeout{module.Module, terragruntOptions.RunTimeout, true, err}
+	case runCtx.Err() != nil:
+		return errModuleCancelled{module.Module, err}
+	default:
+		return errModuleTimeout{module.Module, timeout, false, err}
+	}
+}
+
+// Returns the outcome of a finished module
+func (module *runningModule) outcome() string {
+	if module.Err == nil {
+		return outcomeSucceeded
+	}
+	switch tgerrors.Unwrap(module.Err).(type) {
+	case dependencyFinishedWithError:
+		return outcomeSkipped
+	case errModuleCancelled:
+		return outcomeCancelled
+	case errModuleTimeout:
+		return outcomeTimedOut
+	}
+	return outcomeFailed
+}
+
+// Returns the exit code of a finished module (errors without a specific exit code are considered as exit code 1)
+func (module *runningModule) exitCode() int {
+	exitCode, err := shell.GetExitCode(module.Err)
+	if err != nil {
+		return errorExitCode
+	}
+	return exitCode
+}
+
+// Returns the time spent to process the module (zero if the module has never been started)
+func (module *runningModule) duration() time.Duration {
+	if module.startTime.IsZero() || module.endTime.IsZero() {
+		return 0
+	}
+	return module.endTime.Sub(module.startTime)
 }
 
 var separator = strings.Repeat("-", 132)
@@ -276,13 +470,21 @@ func (module *runningModule) moduleFinished(moduleErr error) {
 		output, moduleErr = module.Handler(*module.Module, output, moduleErr)
 	}
 
-	if moduleErr != nil {
+	if cancelledErr, isCancelled := tgerrors.Unwrap(moduleErr).(errModuleCancelled); isCancelled && cancelledErr.Err == nil {
+		status = "without being started (cancelled)"
+		logFinish = module.Module.TerragruntOptions.Logger.Warningf
+	} else if moduleErr != nil {
 		status = fmt.Sprintf("with an error: %v", moduleErr)
 		logFinish = module.Module.TerragruntOptions.Logger.Errorf
 	}
+	if module.attempts > 1 {
+		status = fmt.Sprintf("%s (after %d attempts)", status, module.attempts)
+	}
 
 	module.Mutex.Lock()
 	defer module.Mutex.Unlock()
+	module.progress.suspend()
+	defer module.progress.resume()
 	logFinish("Module %s has finished %s", module.displayName(), status)
 
 	if output == "" {
@@ -294,10 +496,24 @@ func (module *runningModule) moduleFinished(moduleErr error) {
 
 	module.Status = finished
 	module.Err = moduleErr
+	module.endTime = time.Now()
+	if module.outcome() == outcomeCancelled {
+		module.Status = cancelled
+	}
+	module.progress.finished(module)
+
+	if err := module.journal.record(module); err != nil {
+		module.Module.TerragruntOptions.Logger.Warningf("Unable to record the outcome of %s in the run journal: %v", module.displayName(), err)
+	}
 
 	for _, toNotify := range module.NotifyWhenDone {
 		toNotify.DependencyDone <- module
 	}
+
+	if outcome := module.outcome(); (outcome == outcomeFailed || outcome == outcomeTimedOut) && module.Module.TerragruntOptions.FailFast && module.ctx.Err() == nil {
+		module.Module.TerragruntOptions.Logger.Warningf("Cancelling the modules that are not already started since %s failed (fail fast mode)", module.displayName())
+		module.cancel()
+	}
 }
 
 // Custom error types
@@ -324,11 +540,20 @@ type errMulti struct {
 }
 
 func (e errMulti) Error() string {
-	errorStrings := []string{}
+	errorStrings, cancelledModules := []string{}, []string{}
 	for _, err := range e.Errors {
+		if cancelled, isCancelled := tgerrors.Unwrap(err).(errModuleCancelled); isCancelled {
+			cancelledModules = append(cancelledModules, util.GetPathRelativeToWorkingDir(cancelled.Module.Path))
+			continue
+		}
 		errorStrings = append(errorStrings, err.Error())
 	}
-	return fmt.Sprintf("Encountered the following errors:\n%s", strings.Join(errorStrings, "\n"))
+	result := fmt.Sprintf("Encountered the following errors:\n%s", strings.Join(errorStrings, "\n"))
+	if len(cancelledModules) > 0 {
+		sort.Strings(cancelledModules)
+		result += fmt.Sprintf("\nThe following modules have been cancelled:\n  %s", strings.Join(cancelledModules, "\n  "))
+	}
+	return result
 }
 
 func (e errMulti) ExitStatus() (int, error) {
@@ -343,6 +568,46 @@ func (e errMulti) ExitStatus() (int, error) {
 	return exitCode, nil
 }
 
+type errModuleCancelled struct {
+	Module *TerraformModule
+	Err    error // The error returned by the module if it has been interrupted while running
+}
+
+func (e errModuleCancelled) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Module %s has been interrupted: %v", e.Module.Path, e.Err)
+	}
+	return fmt.Sprintf("Module %s has been cancelled", e.Module.Path)
+}
+
+func (e errModuleCancelled) ExitStatus() (int, error) {
+	if exitCode, err := shell.GetExitCode(e.Err); err == nil && exitCode != normalExitCode {
+		return exitCode, nil
+	}
+	return errorExitCode, nil
+}
+
+type errModuleTimeout struct {
+	Module  *TerraformModule
+	Timeout time.Duration
+	Global  bool  // Indicates that the whole execution has timed out
+	Err     error // The error returned by the module when it has been interrupted
+}
+
+func (e errModuleTimeout) Error() string {
+	if e.Global {
+		return fmt.Sprintf("Module %s has been interrupted because the execution timed out after %v: %v", e.Module.Path, e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("Module %s timed out after %v: %v", e.Module.Path, e.Timeout, e.Err)
+}
+
+func (e errModuleTimeout) ExitStatus() (int, error) {
+	if exitCode, err := shell.GetExitCode(e.Err); err == nil && exitCode != normalExitCode {
+		return exitCode, nil
+	}
+	return errorExitCode, nil
+}
+
 type errDependencyNotFoundWhileCrossLinking struct {
 	Module     *runningModule
 	Dependency *TerraformModule
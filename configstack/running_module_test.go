@@ -1,8 +1,11 @@
 package configstack
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/coveooss/terragrunt/v2/config"
 	"github.com/coveooss/terragrunt/v2/options"
@@ -1073,3 +1076,289 @@ func TestRunModulesReverseOrderMultipleModulesWithDependenciesLargeGraphPartialF
 	assert.True(t, eRan)
 	assert.True(t, fRan)
 }
+
+func TestRunModulesFailFastCancelsWaitingModules(t *testing.T) {
+	t.Parallel()
+
+	failFast := func(opts *options.TerragruntOptions) *options.TerragruntOptions {
+		opts.FailFast = true
+		return opts
+	}
+
+	aRan := false
+	expectedErrA := fmt.Errorf("Expected error for module a")
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: failFast(optionsWithMockTerragruntCommand("a", expectedErrA, &aRan)),
+	}
+	moduleA.TerragruntOptions.RunTerragrunt = func(*options.TerragruntOptions) error {
+		// Give enough time to module b to start
+		time.Sleep(100 * time.Millisecond)
+		aRan = true
+		return expectedErrA
+	}
+
+	bRan := false
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: failFast(optionsWithMockTerragruntCommand("b", nil, &bRan)),
+	}
+	moduleB.TerragruntOptions.RunTerragrunt = func(*options.TerragruntOptions) error {
+		// Module b is still running when module a fails
+		time.Sleep(500 * time.Millisecond)
+		bRan = true
+		return nil
+	}
+
+	cRan := false
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{moduleB},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: failFast(optionsWithMockTerragruntCommand("c", nil, &cRan)),
+	}
+
+	dRan := false
+	moduleD := &TerraformModule{
+		Path:              "d",
+		Dependencies:      []*TerraformModule{moduleC},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: failFast(optionsWithMockTerragruntCommand("d", nil, &dRan)),
+	}
+
+	err := runModules([]*TerraformModule{moduleA, moduleB, moduleC, moduleD})
+	assertMultiErrorContains(t, err, expectedErrA, errModuleCancelled{moduleC, nil}, errModuleCancelled{moduleD, nil})
+	assert.Contains(t, err.Error(), "The following modules have been cancelled:\n  c\n  d")
+
+	assert.True(t, aRan)
+	assert.True(t, bRan, "Running modules should not be interrupted")
+	assert.False(t, cRan)
+	assert.False(t, dRan)
+}
+
+func TestRunModulesModuleTimeout(t *testing.T) {
+	t.Parallel()
+
+	// Simulate a command that runs until it is interrupted
+	errInterrupted := fmt.Errorf("Interrupted")
+	hangUntilInterrupted := func(ran *bool) func(*options.TerragruntOptions) error {
+		return func(opts *options.TerragruntOptions) error {
+			*ran = true
+			<-opts.RunContext.Done()
+			return errInterrupted
+		}
+	}
+
+	timeout := "100ms"
+	aRan := false
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{Timeout: &timeout},
+		TerragruntOptions: optionsWithMockTerragruntCommand("a", nil, &aRan),
+	}
+	moduleA.TerragruntOptions.RunTerragrunt = hangUntilInterrupted(&aRan)
+
+	bRan := false
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{moduleA},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: optionsWithMockTerragruntCommand("b", nil, &bRan),
+	}
+
+	cRan := false
+	moduleC := &TerraformModule{
+		Path:              "c",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: optionsWithMockTerragruntCommand("c", nil, &cRan),
+	}
+	moduleC.TerragruntOptions.ModuleTimeout = time.Minute
+
+	err := runModules([]*TerraformModule{moduleA, moduleB, moduleC})
+	expectedErrA := errModuleTimeout{moduleA, 100 * time.Millisecond, false, errInterrupted}
+	assertMultiErrorContains(t, err, expectedErrA, dependencyFinishedWithError{moduleB, moduleA, expectedErrA})
+	assert.Contains(t, err.Error(), "Module a timed out after 100ms: Interrupted")
+
+	assert.True(t, aRan)
+	assert.False(t, bRan)
+	assert.True(t, cRan)
+}
+
+func TestRunModulesRunTimeout(t *testing.T) {
+	t.Parallel()
+
+	runTimeout := func(opts *options.TerragruntOptions) *options.TerragruntOptions {
+		opts.RunTimeout = 100 * time.Millisecond
+		return opts
+	}
+
+	errInterrupted := fmt.Errorf("Interrupted")
+	aRan := false
+	moduleA := &TerraformModule{
+		Path:              "a",
+		Dependencies:      []*TerraformModule{},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: runTimeout(optionsWithMockTerragruntCommand("a", nil, &aRan)),
+	}
+	moduleA.TerragruntOptions.RunTerragrunt = func(opts *options.TerragruntOptions) error {
+		aRan = true
+		<-opts.RunContext.Done()
+		// Give time to the pending modules to be cancelled
+		time.Sleep(50 * time.Millisecond)
+		return errInterrupted
+	}
+
+	bRan := false
+	moduleB := &TerraformModule{
+		Path:              "b",
+		Dependencies:      []*TerraformModule{moduleA},
+		Config:            config.TerragruntConfig{},
+		TerragruntOptions: runTimeout(optionsWithMockTerragruntCommand("b", nil, &bRan)),
+	}
+
+	err := runModules([]*TerraformModule{moduleA, moduleB})
+	assertMultiErrorContains(t, err, errModuleTimeout{moduleA, 100 * time.Millisecond, true, errInterrupted}, errModuleCancelled{moduleB, nil})
+	assert.Contains(t, err.Error(), "Module a has been interrupted because the execution timed out after 100ms: Interrupted")
+
+	assert.True(t, aRan)
+	assert.False(t, bRan)
+}
+
+func TestRunModulesConcurrencyGroups(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	running, maxRunning := map[string]int{}, map[string]int{}
+	track := func(group string, ran *bool) func(*options.TerragruntOptions) error {
+		return func(*options.TerragruntOptions) error {
+			mutex.Lock()
+			running[group]++
+			if running[group] > maxRunning[group] {
+				maxRunning[group] = running[group]
+			}
+			mutex.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+
+			mutex.Lock()
+			running[group]--
+			mutex.Unlock()
+			*ran = true
+			return nil
+		}
+	}
+
+	var modules []*TerraformModule
+	ran := make([]bool, 6)
+	for i := range ran {
+		group := config.ConcurrencyGroup{Name: "account", Max: 2}
+		if i == 0 {
+			// The lowest maximum defined for a group is used
+			group.Max = 1
+		}
+		module := &TerraformModule{
+			Path:              fmt.Sprintf("module-%d", i),
+			Dependencies:      []*TerraformModule{},
+			Config:            config.TerragruntConfig{ConcurrencyGroups: []config.ConcurrencyGroup{group}},
+			TerragruntOptions: optionsWithMockTerragruntCommand(fmt.Sprintf("module-%d", i), nil, &ran[i]),
+		}
+		module.TerragruntOptions.RunTerragrunt = track("account", &ran[i])
+		modules = append(modules, module)
+	}
+
+	err := runModules(modules)
+	assert.Nil(t, err, "Unexpected error: %v", err)
+	for i := range ran {
+		assert.True(t, ran[i])
+	}
+	assert.Equal(t, 1, maxRunning["account"])
+}
+
+func TestSchedulerRampUp(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rampUp := 50 * time.Millisecond
+	scheduler := newScheduler(ctx, 3, rampUp)
+	assert.Equal(t, 3, scheduler.nbWorkers())
+
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		token, acquired := scheduler.waitWorker(0, nil)
+		assert.True(t, acquired)
+		assert.Equal(t, i, token)
+	}
+	assert.True(t, time.Since(start) >= 2*rampUp, "The workers should be made available progressively")
+
+	// A worker is available again as soon as it is freed
+	scheduler.freeWorker(2)
+	token, acquired := scheduler.waitWorker(0, nil)
+	assert.True(t, acquired)
+	assert.Equal(t, 2, token)
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newScheduler(context.Background(), 1, 0)
+	token, _ := scheduler.waitWorker(0, nil)
+
+	// The modules are waiting for the worker in the order of their priorities, except the last two having the same priority
+	priorities := []time.Duration{time.Second, 3 * time.Second, 2 * time.Second, 2 * time.Second}
+	granted := make(chan int, len(priorities))
+	for i, priority := range priorities {
+		go func(i int, priority time.Duration) {
+			token, _ := scheduler.waitWorker(priority, nil)
+			granted <- i
+			scheduler.freeWorker(token)
+		}(i, priority)
+		// Wait for the request to be queued to ensure a deterministic arrival order
+		for {
+			scheduler.mutex.Lock()
+			queued := len(scheduler.waiting)
+			scheduler.mutex.Unlock()
+			if queued == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	scheduler.freeWorker(token)
+	var order []int
+	for range priorities {
+		order = append(order, <-granted)
+	}
+	assert.Equal(t, []int{1, 2, 3, 0}, order)
+}
+
+func TestSchedulerCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := newScheduler(ctx, 2, time.Hour)
+	_, acquired := scheduler.waitWorker(0, nil)
+	assert.True(t, acquired)
+
+	cancel()
+	_, acquired = scheduler.waitWorker(0, ctx.Done())
+	assert.False(t, acquired)
+}
+
+func TestRunningModuleDisplayName(t *testing.T) {
+	t.Parallel()
+
+	module := runningModule{Module: &TerraformModule{Path: "a"}, workerID: 3}
+	assert.Equal(t, "Worker #3: a", module.displayName())
+
+	module.scheduler = newScheduler(context.Background(), 12, 0)
+	assert.Equal(t, "Worker #03: a", module.displayName())
+}
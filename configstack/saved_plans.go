@@ -0,0 +1,249 @@
+package configstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// PlanManifestFile is the file describing the plans saved by plan-all in the plan folder
+const PlanManifestFile = "manifest.json"
+
+// planManifest describes the plans saved by plan-all (by module path relative to the stack)
+type planManifest struct {
+	Modules map[string]savedPlan `json:"modules"`
+}
+
+// savedPlan describes the plan of a module along with the state of the module when the plan has been made
+type savedPlan struct {
+	PlanFile   string `json:"plan_file"`
+	SourceHash string `json:"source_hash"`
+	InputsHash string `json:"inputs_hash"`
+	HasChanges bool   `json:"has_changes"`
+}
+
+// Returns the key of the module in the manifest (its path relative to the stack)
+func (stack *Stack) planKey(module *TerraformModule) string {
+	key, err := util.GetPathRelativeTo(module.Path, stack.Path)
+	if err != nil {
+		return module.Path
+	}
+	return key
+}
+
+// Returns the plan files of the modules (by module path) in the plan folder. An error is returned if two modules would
+// share the same plan file.
+func (stack *Stack) planFiles(planDir string) (map[string]string, error) {
+	files := make(map[string]string, len(stack.Modules))
+	modules := make(map[string]string, len(stack.Modules))
+	for _, module := range stack.Modules {
+		key := stack.planKey(module)
+		file := filepath.Join(planDir, planFileName(key))
+		if other, found := modules[file]; found && other != key {
+			return nil, tgerrors.WithStackTrace(errPlanFileCollision{file, other, key})
+		}
+		modules[file] = key
+		files[module.Path] = file
+	}
+	return files, nil
+}
+
+// Write the manifest of the plans that succeeded in the plan folder
+func (stack *Stack) savePlanManifest(planDir string, results []moduleResult) error {
+	manifest := planManifest{Modules: map[string]savedPlan{}}
+	modules := map[string]string{}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		module := &result.Module
+		sourceHash, inputsHash, err := moduleHashes(module)
+		if err != nil {
+			return err
+		}
+		key := stack.planKey(module)
+		planFile := planFileName(key)
+		if other, found := modules[planFile]; found && other != key {
+			return tgerrors.WithStackTrace(errPlanFileCollision{planFile, other, key})
+		}
+		modules[planFile] = key
+		manifest.Modules[key] = savedPlan{
+			PlanFile:   planFile,
+			SourceHash: sourceHash,
+			InputsHash: inputsHash,
+			// If the number of changes cannot be determined, we consider that there are changes to apply
+			HasChanges: result.NbChanges != 0,
+		}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	return tgerrors.WithStackTrace(ioutil.WriteFile(filepath.Join(planDir, PlanManifestFile), content, 0644))
+}
+
+// Load the manifest of the plans saved in the plan folder
+func loadPlanManifest(planDir string) (*planManifest, error) {
+	content, err := ioutil.ReadFile(filepath.Join(planDir, PlanManifestFile))
+	if err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+	var manifest planManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, tgerrors.WithStackTrace(fmt.Errorf("invalid plan manifest %s: %v", filepath.Join(planDir, PlanManifestFile), err))
+	}
+	return &manifest, nil
+}
+
+// Returns an error if the module has changed since its plan has been saved
+func (plan savedPlan) check(module *TerraformModule, key string) error {
+	sourceHash, inputsHash, err := moduleHashes(module)
+	if err != nil {
+		return err
+	}
+	if sourceHash != plan.SourceHash {
+		return errPlanOutdated{key, "source"}
+	}
+	if inputsHash != plan.InputsHash {
+		return errPlanOutdated{key, "configuration"}
+	}
+	return nil
+}
+
+// Returns the hash of the terraform source of the module and the hash of its configuration (every file read to build
+// the config, the var files and the inputs)
+//
+// The local sources are hashed with the content of their files, but the remote sources are only identified by their
+// URL. So a change of a remote source that is not pinned to a version (i.e. a branch) is not detected.
+func moduleHashes(module *TerraformModule) (sourceHash, inputsHash string, err error) {
+	conf, err := moduleConfig(module)
+	if err != nil {
+		return "", "", err
+	}
+
+	var source string
+	if conf.Terraform != nil {
+		source = conf.Terraform.Source
+	}
+	sourceFolder := module.Path
+	if filepath.IsAbs(source) {
+		sourceFolder = source
+	} else if source != "" {
+		sourceFolder = filepath.Join(module.Path, source)
+	}
+	if stat, statErr := os.Stat(sourceFolder); statErr == nil && stat.IsDir() {
+		if sourceHash, err = util.EncodeBase64Sha1Folder(sourceFolder); err != nil {
+			return "", "", tgerrors.WithStackTrace(err)
+		}
+	} else {
+		// The source is remote, it is identified by its URL (that should include the version)
+		sourceHash = util.EncodeBase64Sha1(source)
+	}
+
+	// The files are read again since they may have changed after the configuration has been parsed
+	files := make(map[string]string, len(conf.SourceFiles()))
+	paths := conf.VarFiles()
+	for file := range conf.SourceFiles() {
+		paths = append(paths, file)
+	}
+	for _, file := range paths {
+		// A file that cannot be read anymore (i.e. a downloaded boot configuration) is considered as changed
+		if content, err := ioutil.ReadFile(file); err == nil {
+			files[file] = util.EncodeBase64Sha1(string(content))
+		} else {
+			files[file] = ""
+		}
+	}
+	// The maps are serialized with sorted keys, so the hash does not depend on the order of the files
+	content, err := json.Marshal(map[string]interface{}{"files": files, "inputs": conf.Inputs})
+	if err != nil {
+		return "", "", tgerrors.WithStackTrace(err)
+	}
+	return sourceHash, util.EncodeBase64Sha1(string(content)), nil
+}
+
+// Returns the complete configuration of the module. The configuration of the stack only contains the elements required
+// to build the stack if it has been loaded from the discovery cache, so it is parsed again in that case.
+func moduleConfig(module *TerraformModule) (*config.TerragruntConfig, error) {
+	if len(module.Config.SourceFiles()) > 0 {
+		return &module.Config, nil
+	}
+	terragruntOptions := module.TerragruntOptions.Clone(module.TerragruntOptions.TerragruntConfigPath)
+	terragruntOptions.SkipDependencyOutputs = true
+	return config.ReadTerragruntConfig(terragruntOptions)
+}
+
+// ApplyPlans applies the plans saved by plan-all in the plan folder in the dependency order. The modules that have
+// changed since the plan (or that have no plan) are refused and the modules without changes are skipped.
+func (stack *Stack) ApplyPlans(command string, planDir string, terragruntOptions *options.TerragruntOptions) error {
+	planDir, err := filepath.Abs(planDir)
+	if err != nil {
+		return tgerrors.WithStackTrace(err)
+	}
+	manifest, err := loadPlanManifest(planDir)
+	if err != nil {
+		return err
+	}
+
+	// Terraform never asks for approval when applying a saved plan, but -auto-approve must be specified before the plan file
+	stack.setTerraformCommand([]string{command, "-input=false", "-auto-approve"})
+	for _, module := range stack.Modules {
+		key := stack.planKey(module)
+		plan, found := manifest.Modules[key]
+		if !found {
+			err = errPlanNotFound(key)
+		} else {
+			err = plan.check(module, key)
+		}
+
+		switch {
+		case err != nil:
+			refusal := err
+			module.TerragruntOptions.RunTerragrunt = func(*options.TerragruntOptions) error { return refusal }
+		case !plan.HasChanges:
+			module.TerragruntOptions.RunTerragrunt = func(terragruntOptions *options.TerragruntOptions) error {
+				terragruntOptions.Logger.Info("The saved plan has no change, skipping the module")
+				return nil
+			}
+		default:
+			// The plan file must be the last argument since the extra arguments are inserted after the command
+			module.TerragruntOptions.TerraformCliArgs = append(module.TerragruntOptions.TerraformCliArgs, filepath.Join(planDir, plan.PlanFile))
+		}
+	}
+	return runModulesWithHandler(stack.Modules, nil, NormalOrder)
+}
+
+// Custom error types
+
+type errPlanNotFound string
+
+func (err errPlanNotFound) Error() string {
+	return fmt.Sprintf("There is no saved plan for module %s, run plan-all again", string(err))
+}
+
+type errPlanOutdated struct {
+	module string
+	what   string
+}
+
+func (err errPlanOutdated) Error() string {
+	return fmt.Sprintf("The %s of module %s has changed since the plan has been saved, run plan-all again", err.what, err.module)
+}
+
+type errPlanFileCollision struct {
+	file    string
+	module1 string
+	module2 string
+}
+
+func (err errPlanFileCollision) Error() string {
+	return fmt.Sprintf("The plans of modules %s and %s would be saved in the same file %s", err.module1, err.module2, err.file)
+}
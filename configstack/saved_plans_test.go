@@ -0,0 +1,184 @@
+package configstack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/config"
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySavedPlans(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "saved-plans")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	planDir := filepath.Join(folder, "plans")
+	assert.NoError(t, os.MkdirAll(planDir, 0755))
+
+	var mutex sync.Mutex
+	executed := map[string][]string{}
+	createModule := func(name string, dependencies ...*TerraformModule) *TerraformModule {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(path, 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(path, "main.tf"), []byte("# "+name), 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(path, "terragrunt.hcl"), []byte(""), 0644))
+		var ran bool
+		opts := optionsWithMockTerragruntCommand(filepath.Join(path, "terragrunt.hcl"), nil, &ran)
+		opts.RunTerragrunt = func(opts *options.TerragruntOptions) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			executed[name] = opts.TerraformCliArgs
+			return nil
+		}
+		return &TerraformModule{
+			Path:              path,
+			Dependencies:      dependencies,
+			Config:            config.TerragruntConfig{Inputs: map[string]interface{}{"name": name}},
+			TerragruntOptions: opts,
+		}
+	}
+	moduleA := createModule("a")
+	moduleB := createModule("b", moduleA)
+	moduleC := createModule("c")
+	moduleD := createModule("d")
+	stack := &Stack{Path: folder, Modules: []*TerraformModule{moduleA, moduleB, moduleC, moduleD}}
+
+	assert.NoError(t, stack.savePlanManifest(planDir, []moduleResult{
+		{Module: *moduleA, NbChanges: 0},
+		{Module: *moduleB, NbChanges: 2},
+		{Module: *moduleC, NbChanges: 1},
+		{Module: *moduleD, NbChanges: 1, Err: assert.AnError},
+	}))
+
+	// The source of c is modified after the plan
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "c", "main.tf"), []byte("# modified"), 0644))
+
+	err = stack.ApplyPlans("apply", planDir, mockOptions)
+	errs := tgerrors.Unwrap(err).(errMulti).Errors
+	if assert.Len(t, errs, 2) {
+		assert.ElementsMatch(t, []error{errPlanOutdated{"c", "source"}, errPlanNotFound("d")}, []error{tgerrors.Unwrap(errs[0]), tgerrors.Unwrap(errs[1])})
+	}
+	assert.Equal(t, map[string][]string{
+		"b": {"apply", "-input=false", "-auto-approve", filepath.Join(planDir, planFileName("b"))},
+	}, executed)
+}
+
+func TestPlanFileName(t *testing.T) {
+	t.Parallel()
+
+	assert.Regexp(t, `^network_vpc-[\w-]{12}\.tfplan$`, planFileName("network/vpc"))
+	assert.Regexp(t, `^root-[\w-]{12}\.tfplan$`, planFileName("."))
+	assert.NotEqual(t, planFileName("a_b/c"), planFileName("a/b_c"))
+	assert.NotEqual(t, planFileName("../x"), planFileName("x"))
+}
+
+func TestSavedPlanOutdatedByIncludedFiles(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "saved-plans-includes")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	write := func(name, content string) {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	write("common.hcl", `
+		terraform {
+			extra_arguments "vars" {
+				commands           = ["plan"]
+				optional_var_files = ["../vars/app.tfvars"]
+			}
+		}
+	`)
+	write("app/main.tf", "")
+	write("vars/app.tfvars", "count = 1")
+	write("app/terragrunt.hcl", `include { path = "../common.hcl" }`)
+
+	module, _, err := resolveTerraformModule(filepath.Join(folder, "app", "terragrunt.hcl"), options.NewTerragruntOptionsForTest(filepath.Join(folder, "app", "terragrunt.hcl")))
+	assert.NoError(t, err)
+	stack := &Stack{Path: folder, Modules: []*TerraformModule{module}}
+	assert.NoError(t, stack.savePlanManifest(folder, []moduleResult{{Module: *module, NbChanges: 1}}))
+	manifest, err := loadPlanManifest(folder)
+	assert.NoError(t, err)
+	plan := manifest.Modules["app"]
+	assert.NoError(t, plan.check(module, "app"))
+
+	write("vars/app.tfvars", "count = 2")
+	assert.Equal(t, errPlanOutdated{"app", "configuration"}, plan.check(module, "app"), "The var files are part of the configuration")
+
+	write("vars/app.tfvars", "count = 1")
+	write("common.hcl", `
+		terraform {
+			extra_arguments "vars" {
+				commands           = ["plan", "apply"]
+				optional_var_files = ["../vars/app.tfvars"]
+			}
+		}
+	`)
+	assert.Equal(t, errPlanOutdated{"app", "configuration"}, plan.check(module, "app"), "The included files are part of the configuration")
+}
+
+func TestSavedPlanOutdatedByAbsoluteSource(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "saved-plans-source")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	write := func(name, content string) {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	write("modules/app/main.tf", "")
+	write("app/terragrunt.hcl", fmt.Sprintf(`terraform { source = %q }`, filepath.Join(folder, "modules", "app")))
+
+	module, _, err := resolveTerraformModule(filepath.Join(folder, "app", "terragrunt.hcl"), options.NewTerragruntOptionsForTest(filepath.Join(folder, "app", "terragrunt.hcl")))
+	assert.NoError(t, err)
+	sourceHash, _, err := moduleHashes(module)
+	assert.NoError(t, err)
+
+	write("modules/app/main.tf", `output "name" { value = "app" }`)
+	newSourceHash, _, err := moduleHashes(module)
+	assert.NoError(t, err)
+	assert.NotEqual(t, sourceHash, newSourceHash, "The content of an absolute local source should be hashed")
+}
+
+func TestPlanResultHandlerRecordsModulesWithoutOutput(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "plan-results")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	opts := options.NewTerragruntOptionsForTest(filepath.Join(folder, "terragrunt.hcl"))
+	opts.TerraformPath = "false"
+	saved := TerraformModule{Path: filepath.Join(folder, "saved"), TerragruntOptions: opts}
+	skipped := TerraformModule{Path: filepath.Join(folder, "skipped"), TerragruntOptions: opts}
+	planFiles := map[string]string{
+		saved.Path:   filepath.Join(folder, "saved.tfplan"),
+		skipped.Path: filepath.Join(folder, "skipped.tfplan"),
+	}
+	assert.NoError(t, ioutil.WriteFile(planFiles[saved.Path], []byte("plan"), 0644))
+
+	var results []moduleResult
+	var hasChanges bool
+	handler := getResultHandler(false, planFiles, nil, &results, &hasChanges)
+	handler(saved, "", nil)
+	handler(skipped, "", nil)
+
+	if assert.Len(t, results, 1, "Only the module that saved its plan should be recorded") {
+		assert.Equal(t, saved.Path, results[0].Module.Path)
+		assert.NotZero(t, results[0].NbChanges, "A plan that cannot be analyzed is considered as having changes")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/util"
 )
 
 // Stack represents a stack of Terraform modules (i.e. folders with Terraform templates) that you can "spin up" or
@@ -76,6 +77,9 @@ func (stack *Stack) Plan(command string, terragruntOptions *options.TerragruntOp
 
 // Output prints the outputs of all the modules in the given stack in their specified order.
 func (stack *Stack) Output(command string, terragruntOptions *options.TerragruntOptions) error {
+	if terragruntOptions.AggregateOutput {
+		return stack.outputAggregated(command, terragruntOptions)
+	}
 	stack.setTerraformCommand([]string{command})
 	handler := func(module TerraformModule, output string, err error) (string, error) {
 		if err != nil && strings.Contains(output, "no outputs defined") {
@@ -97,13 +101,20 @@ func (stack *Stack) checkForCycles() error {
 	return checkForCycles(stack.Modules)
 }
 
-// FindStackInSubfolders finds all the Terraform modules in the subfolders of the working directory of the given TerragruntOptions and
-// assemble them into a Stack object that can be applied or destroyed in a single command
+// FindStackInSubfolders finds all the Terraform modules in the subfolders of the root folders (the working directory by
+// default) of the given TerragruntOptions and assemble them into a Stack object that can be applied or destroyed in a
+// single command. The modules of all the root folders are part of the same stack, so the dependencies between them are
+// not considered as external dependencies.
 func FindStackInSubfolders(terragruntOptions *options.TerragruntOptions) (*Stack, error) {
-	terragruntConfigFiles, err := terragruntOptions.FindConfigFilesInPath("")
-	if err != nil {
-		return nil, err
+	var terragruntConfigFiles []string
+	for _, root := range terragruntOptions.StackRootFolders() {
+		configFiles, err := terragruntOptions.FindConfigFilesInPath(root)
+		if err != nil {
+			return nil, err
+		}
+		terragruntConfigFiles = append(terragruntConfigFiles, configFiles...)
 	}
+	terragruntConfigFiles = util.RemoveDuplicatesFromListKeepFirst(terragruntConfigFiles)
 	if len(terragruntConfigFiles) == 0 {
 		terragruntOptions.Logger.Warning("Could not find any subfolders with Terragrunt configuration files")
 	}
@@ -132,8 +143,12 @@ func (stack *Stack) setTerraformCommand(command []string) {
 }
 
 // Custom error types
-type errDependencyCycle []string
+type errDependencyCycle []dependencyCycle
 
 func (err errDependencyCycle) Error() string {
-	return fmt.Sprintf("Found a dependency cycle between modules: %s", strings.Join([]string(err), " -> "))
+	cycles := make([]string, len(err))
+	for i := range err {
+		cycles[i] = err[i].String()
+	}
+	return fmt.Sprintf("Found %d dependency cycle(s) between modules (only one cycle is shown for each group of interdependent modules, fixing it may reveal other ones):\n%s", len(err), strings.Join(cycles, "\n"))
 }
@@ -0,0 +1,101 @@
+package configstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+	"github.com/coveooss/terragrunt/v2/util"
+)
+
+// Run terraform output -json on all modules of the stack and print a single JSON document containing the outputs of
+// each module (by module path relative to the working dir). The outputs are kept as returned by terraform (with their
+// value, type and sensitive flag) and the modules without outputs are represented by an empty object. The modules that
+// failed or have been skipped have null outputs along with the error.
+func (stack *Stack) outputAggregated(command string, terragruntOptions *options.TerragruntOptions) error {
+	args := []string{command}
+	if !util.ListContainsElement(terragruntOptions.TerraformCliArgs, "-json") {
+		args = append(args, "-json")
+	}
+	stack.setTerraformCommand(args)
+
+	// The JSON document is captured apart from the output of the hooks and the other terraform commands (i.e. init)
+	documents := make(map[string]*bytes.Buffer, len(stack.Modules))
+	for _, module := range stack.Modules {
+		documents[module.Path] = new(bytes.Buffer)
+		module.TerragruntOptions.CommandWriter = documents[module.Path]
+	}
+
+	var mutex sync.Mutex
+	outputs := make(map[string]aggregatedOutput, len(stack.Modules))
+	handler := func(module TerraformModule, output string, err error) (string, error) {
+		moduleOutputs := map[string]interface{}{}
+		if err != nil && !strings.Contains(output, "no outputs defined") {
+			moduleOutputs = nil
+		} else if moduleOutputs, err = parseOutputs(documents[module.Path].String()); err != nil {
+			err = tgerrors.WithStackTrace(errInvalidOutputs{module.Path, err})
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		result := aggregatedOutput{Outputs: moduleOutputs}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		outputs[aggregatedOutputKey(stack.Path, module.Path)] = result
+		if err != nil {
+			return output, err
+		}
+		return "", nil
+	}
+	err := runModulesWithHandler(stack.Modules, handler, NormalOrder)
+
+	result, jsonErr := json.MarshalIndent(outputs, "", "  ")
+	if jsonErr != nil {
+		return tgerrors.WithStackTrace(jsonErr)
+	}
+	fmt.Fprintln(terragruntOptions.Writer, string(result))
+	return err
+}
+
+// aggregatedOutput is the entry of a module in the aggregated outputs
+type aggregatedOutput struct {
+	Outputs map[string]interface{} `json:"outputs"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// Returns the outputs returned by terraform output -json (an empty map if there are no outputs)
+func parseOutputs(output string) (map[string]interface{}, error) {
+	outputs := map[string]interface{}{}
+	if strings.TrimSpace(output) == "" {
+		return outputs, nil
+	}
+	if err := json.Unmarshal([]byte(output), &outputs); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// Returns the key of the module in the aggregated outputs (its path relative to the working dir)
+func aggregatedOutputKey(workingDir, modulePath string) string {
+	if absWorkingDir, err := filepath.Abs(workingDir); err == nil {
+		if relativePath, err := filepath.Rel(absWorkingDir, modulePath); err == nil {
+			return filepath.ToSlash(relativePath)
+		}
+	}
+	return filepath.ToSlash(modulePath)
+}
+
+type errInvalidOutputs struct {
+	path string
+	err  error
+}
+
+func (err errInvalidOutputs) Error() string {
+	return fmt.Sprintf("unable to parse the outputs of %s: %v", util.GetPathRelativeToWorkingDir(err.path), err.err)
+}
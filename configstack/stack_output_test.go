@@ -0,0 +1,91 @@
+package configstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		output  string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"Empty", "", map[string]interface{}{}, false},
+		{"No outputs", "{}\n", map[string]interface{}{}, false},
+		{"Sensitive", `{"password": {"sensitive": true, "type": "string", "value": "secret"}}`, map[string]interface{}{
+			"password": map[string]interface{}{"sensitive": true, "type": "string", "value": "secret"},
+		}, false},
+		{"With preamble", "Initializing...\n{\"id\": {\"sensitive\": false, \"type\": \"string\", \"value\": \"42\"}}", nil, true},
+		{"Not JSON", "Error: something went wrong", nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseOutputs(tt.output)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAggregatedOutputKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "network/vpc", aggregatedOutputKey("/stack", "/stack/network/vpc"))
+	assert.Equal(t, "../shared/dns", aggregatedOutputKey("/stack/network", "/stack/shared/dns"))
+	assert.Equal(t, ".", aggregatedOutputKey("/stack", "/stack"))
+}
+
+func TestOutputAggregated(t *testing.T) {
+	t.Parallel()
+
+	createModule := func(name string, run func(*options.TerragruntOptions) error, dependencies ...*TerraformModule) *TerraformModule {
+		opts := options.NewTerragruntOptionsForTest(filepath.Join("/stack", name, "terragrunt.hcl"))
+		opts.RunTerragrunt = run
+		return &TerraformModule{Path: filepath.Join("/stack", name), Dependencies: dependencies, TerragruntOptions: opts}
+	}
+	moduleA := createModule("a", func(opts *options.TerragruntOptions) error {
+		// The output of the hooks and init is not part of the JSON document
+		fmt.Fprintln(opts.Writer, "Hook output {")
+		fmt.Fprintln(opts.CommandWriter, `{"id": {"sensitive": false, "type": "string", "value": "42"}}`)
+		return nil
+	})
+	moduleB := createModule("b", func(opts *options.TerragruntOptions) error { return assert.AnError })
+	moduleC := createModule("c", func(opts *options.TerragruntOptions) error { return nil }, moduleB)
+	stack := &Stack{Path: "/stack", Modules: []*TerraformModule{moduleA, moduleB, moduleC}}
+
+	output, err := ioutil.TempFile("", "aggregated-outputs")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	terragruntOptions := options.NewTerragruntOptionsForTest("/stack/terragrunt.hcl")
+	terragruntOptions.Writer = output
+
+	assert.Error(t, stack.outputAggregated("output", terragruntOptions))
+	output.Close()
+	content, err := ioutil.ReadFile(output.Name())
+	assert.NoError(t, err)
+
+	var result map[string]aggregatedOutput
+	assert.NoError(t, json.Unmarshal(content, &result))
+	assert.Equal(t, aggregatedOutput{Outputs: map[string]interface{}{
+		"id": map[string]interface{}{"sensitive": false, "type": "string", "value": "42"},
+	}}, result["a"])
+	assert.Nil(t, result["b"].Outputs)
+	assert.Contains(t, result["b"].Error, assert.AnError.Error())
+	if assert.Contains(t, result, "c", "The skipped modules should be reported") {
+		assert.Nil(t, result["c"].Outputs)
+		assert.NotEmpty(t, result["c"].Error)
+	}
+}
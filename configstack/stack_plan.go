@@ -2,6 +2,9 @@ package configstack
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,6 +21,7 @@ type moduleResult struct {
 	Err       error
 	Message   string
 	NbChanges int
+	Summary   *PlanSummary // Only available if the plan has been saved
 }
 
 var planResultRegex = regexp.MustCompile(`(\d+) to add, (\d+) to change, (\d+) to destroy.`)
@@ -31,11 +35,47 @@ func (stack *Stack) planWithSummary(terragruntOptions *options.TerragruntOptions
 
 	detailedExitCode := util.ListContainsElement(terragruntOptions.TerraformCliArgs, "-detailed-exitcode")
 
+	// We save the plans to get the resource changes from terraform show -json (unless the user saves them himself).
+	// The plans are kept (with a manifest) if a plan folder is specified, otherwise they are saved in a temporary folder.
+	var planFiles map[string]string
+	planDir := terragruntOptions.PlanDir
+	if planDir != "" {
+		var err error
+		if planDir, err = filepath.Abs(planDir); err != nil {
+			return tgerrors.WithStackTrace(err)
+		}
+		if err := os.MkdirAll(planDir, 0755); err != nil {
+			return tgerrors.WithStackTrace(err)
+		}
+	} else if !hasPlanOutArgument(terragruntOptions.TerraformCliArgs) {
+		var err error
+		if planDir, err = ioutil.TempDir("", "terragrunt-plans"); err != nil {
+			return tgerrors.WithStackTrace(err)
+		}
+		defer os.RemoveAll(planDir)
+	}
+	if planDir != "" {
+		var err error
+		if planFiles, err = stack.planFiles(planDir); err != nil {
+			return err
+		}
+		for _, module := range stack.Modules {
+			module.TerragruntOptions.TerraformCliArgs = append(module.TerragruntOptions.TerraformCliArgs, "-out="+planFiles[module.Path])
+		}
+	}
+
 	hasChanges := false
 	results := make([]moduleResult, 0, len(stack.Modules))
-	err := runModulesWithHandler(stack.Modules, getResultHandler(detailedExitCode, &results, &hasChanges), NormalOrder)
+	err := runModulesWithHandler(stack.Modules, getResultHandler(detailedExitCode, planFiles, stack.Modules, &results, &hasChanges), NormalOrder)
 	printSummary(terragruntOptions, results)
 
+	if terragruntOptions.PlanDir != "" {
+		if err := stack.savePlanManifest(planDir, results); err != nil {
+			return err
+		}
+		terragruntOptions.Logger.Infof("The plans have been saved in %[1]s, use apply-all --terragrunt-from-plans %[1]s to apply them", planDir)
+	}
+
 	// If there is no error, but -detail-exitcode is specified, we return an error with the number of changes.
 	if err == nil && detailedExitCode {
 		sum := 0
@@ -59,8 +99,13 @@ func (stack *Stack) planWithSummary(terragruntOptions *options.TerragruntOptions
 	return err
 }
 
-// Returns the handler that will be executed after each completion of `terraform plan`
-func getResultHandler(detailedExitCode bool, results *[]moduleResult, hasChanges *bool) ModuleHandler {
+// Returns the handler that will be executed after each completion of `terraform plan`. The summary of the saved plan is
+// also kept on the modules of the stack to be available in the report.
+func getResultHandler(detailedExitCode bool, planFiles map[string]string, modules []*TerraformModule, results *[]moduleResult, hasChanges *bool) ModuleHandler {
+	stackModules := make(map[string]*TerraformModule, len(modules))
+	for _, module := range modules {
+		stackModules[module.Path] = module
+	}
 	return func(module TerraformModule, output string, err error) (string, error) {
 		warnAboutMissingDependencies(module, output)
 		if exitCode, convErr := shell.GetExitCode(err); convErr == nil && detailedExitCode && exitCode == tgerrors.ChangeExitCode {
@@ -71,11 +116,26 @@ func getResultHandler(detailedExitCode bool, results *[]moduleResult, hasChanges
 			err = nil
 		}
 
-		if output != "" {
+		// The modules that ran are recorded even if they have no output (as long as their plan has been saved)
+		planFile, saved := planFiles[module.Path]
+		if output != "" || err != nil || saved && util.FileExists(planFile) {
 			message, count := extractSummaryResultFromPlan(output)
 
+			var summary *PlanSummary
+			if saved && err == nil {
+				var showErr error
+				if summary, showErr = showPlan(module, planFile); showErr != nil {
+					module.TerragruntOptions.Logger.Warningf("Unable to get the resource changes from the saved plan: %v", showErr)
+				} else {
+					message, count = summary.String(), summary.Count()
+					if stackModule := stackModules[module.Path]; stackModule != nil {
+						stackModule.planSummary = summary
+					}
+				}
+			}
+
 			// We add the result to the result list (there is no concurrency problem because it is handled by the running_module)
-			*results = append(*results, moduleResult{module, err, message, count})
+			*results = append(*results, moduleResult{module, err, message, count, summary})
 		}
 
 		return output, err
@@ -102,6 +162,14 @@ func printSummary(terragruntOptions *options.TerragruntOptions, results []module
 		}
 
 		terragruntOptions.Printf(format, util.GetPathRelativeToWorkingDir(result.Module.Path), result.Message, errMsg)
+		if result.Summary != nil {
+			for _, address := range result.Summary.Replaced {
+				terragruntOptions.Printf("        replace: %s\n", address)
+			}
+			for _, address := range result.Summary.Deleted {
+				terragruntOptions.Printf("        delete: %s\n", address)
+			}
+		}
 	}
 }
 
@@ -123,7 +191,8 @@ func warnAboutMissingDependencies(module TerraformModule, output string) {
 func extractSummaryResultFromPlan(output string) (string, int) {
 	const noChangeV012 = "No changes. Infrastructure is up-to-date."
 	const noChangeV013 = "Plan: 0 to add, 0 to change, 0 to destroy."
-	if strings.Contains(output, noChangeV012) || strings.Contains(output, noChangeV013) {
+	const noChangeV1 = "No changes. Your infrastructure matches the configuration."
+	if strings.Contains(output, noChangeV012) || strings.Contains(output, noChangeV013) || strings.Contains(output, noChangeV1) {
 		return "No change", 0
 	}
 
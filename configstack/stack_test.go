@@ -84,3 +84,40 @@ func createDirIfNotExist(t *testing.T, path string) {
 		}
 	}
 }
+
+func TestFindStackInSeveralRootFolders(t *testing.T) {
+	t.Parallel()
+
+	tempFolder := createTempFolder(t)
+	defer os.RemoveAll(tempFolder)
+	writeDummyTerragruntConfigs(t, tempFolder, []string{
+		"/network/vpc/" + config.DefaultConfigName,
+		"/shared-services/dns/" + config.DefaultConfigName,
+		"/other/unused/" + config.DefaultConfigName,
+	})
+	appConfig := util.JoinPath(tempFolder, "/shared-services/app/"+config.DefaultConfigName)
+	createDirIfNotExist(t, filepath.Dir(appConfig))
+	assert.NoError(t, ioutil.WriteFile(appConfig, []byte(`
+		terraform { source = "test" }
+		dependencies { paths = ["../../network/vpc"] }
+	`), os.ModePerm))
+
+	terragruntOptions := options.NewTerragruntOptionsForTest(util.JoinPath(tempFolder, config.DefaultConfigName))
+	terragruntOptions.WorkingDir = tempFolder
+	terragruntOptions.StackRoots = []string{util.JoinPath(tempFolder, "network"), util.JoinPath(tempFolder, "shared-services")}
+
+	stack, err := FindStackInSubfolders(terragruntOptions)
+	assert.NoError(t, err)
+
+	modules := map[string]*TerraformModule{}
+	for _, module := range stack.Modules {
+		modules[strings.TrimPrefix(filepath.ToSlash(module.Path), tempFolder)] = module
+	}
+	assert.Len(t, modules, 3)
+	assert.NotContains(t, modules, "/other/unused")
+	if app, vpc := modules["/shared-services/app"], modules["/network/vpc"]; assert.NotNil(t, app) && assert.NotNil(t, vpc) {
+		// The dependency between the root folders is not an external dependency
+		assert.Equal(t, []*TerraformModule{vpc}, app.Dependencies)
+		assert.False(t, vpc.AssumeAlreadyApplied)
+	}
+}
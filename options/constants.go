@@ -4,9 +4,19 @@ package options
 const (
 	IgnoreFile               = "terragrunt.ignore"
 	IgnoreFileNonInteractive = "terragrunt-non-interactive.ignore"
+	IgnorePatternsFile       = ".terragruntignore"
 	DefaultConfigName        = "terragrunt.hcl"
 )
 
+// Phases of the execution of a module reported to the PhaseListener
+const (
+	PhaseDownload    = "download"
+	PhaseImportFiles = "import_files"
+	PhaseInit        = "init"
+	PhaseCommand     = "command"
+	PhaseHooks       = "hooks"
+)
+
 var (
 	configNames []string
 
@@ -13,6 +13,7 @@ const (
 	EnvSourceUpdate        = "TERRAGRUNT_SOURCE_UPDATE"         // Used to configure the --terragrunt-source-update option (flushes the cache) (optional)
 	EnvTFPath              = "TERRAGRUNT_TFPATH"                // Used to configure the path to the terraform command (optional, default terraform)
 	EnvWorkers             = "TERRAGRUNT_WORKERS"               // Used to configure the maximum number of concurrent workers (optional)
+	EnvWorkersRampUp       = "TERRAGRUNT_WORKERS_RAMP_UP"       // Used to configure the delay between the start of each worker (optional, default 2.5s)
 	EnvApplyTemplate       = "TERRAGRUNT_TEMPLATE"              // Used to configure whether or not go template should be applied on terraform (.tf and .tfvars) file
 	EnvTemplatePatterns    = "TERRAGRUNT_TEMPLATE_PATTERNS"     // Used to configure the extra files (other than .tf) that should be processed by go template
 	EnvBootConfigs         = "TERRAGRUNT_BOOT_CONFIGS"          // Used to set defaults configuration when launching terragrunt
@@ -41,4 +42,5 @@ const (
 	EnvLastStatus = "TERRAGRUNT_LAST_STATUS" // Used to publish the last executed command exit code
 	EnvError      = "TERRAGRUNT_ERROR"       // Used to publish the cumulated command error if there are
 	EnvStatus     = "TERRAGRUNT_STATUS"      // Used to publish the status of the execution flow
+	EnvReport     = "TERRAGRUNT_REPORT"      // Used to publish the report file of -all commands to the after_all_hook
 )
@@ -1,6 +1,7 @@
 package options
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -75,6 +76,14 @@ type TerragruntOptions struct {
 	// If you want stderr to go somewhere other than os.stderr
 	ErrWriter io.WriteCloser
 
+	// If set, the stdout of the terraform command is written there instead of Writer, apart from the output of the hooks
+	// and the other commands (not inherited by the cloned options)
+	CommandWriter io.Writer
+
+	// The folder where the terraform command has been run (i.e. the download folder if the module has a source), it is
+	// set by RunTerragrunt (not inherited by the cloned options)
+	CommandWorkingDir string
+
 	// A command that can be used to run Terragrunt with the given options. This is useful for running Terragrunt
 	// multiple times (e.g. when spinning up a stack of Terraform modules). The actual command is normally defined
 	// in the cli package, which depends on almost all other packages, so we declare it here so that other
@@ -93,6 +102,12 @@ type TerragruntOptions struct {
 	// Indicates the number of concurrent workers
 	NbWorkers int
 
+	// SchedulePolicy determines which module gets a worker first when many modules are ready (critical-path or fifo)
+	SchedulePolicy string
+
+	// WorkersRampUp is the delay between the start of each worker on -all commands (0 = all workers are available immediately)
+	WorkersRampUp time.Duration
+
 	// ApplyTemplate configures whether or not go template should be applied on terraform (.tf and .tfvars) file
 	ApplyTemplate bool
 
@@ -107,6 +122,96 @@ type TerragruntOptions struct {
 
 	// PluginsDirectory is used to restrict plugin downloads to a specific directory (no remote plugins will be downloaded if this is set)
 	PluginsDirectory string
+
+	// ResumeRunID is the id of a previous -all run to resume (modules that succeeded in that run are skipped)
+	ResumeRunID string
+
+	// If set to true, -all commands stop launching new modules as soon as a module fails
+	FailFast bool
+
+	// If set to true (and FailFast is set), the modules that are running when a module fails are interrupted
+	FailFastInterrupt bool
+
+	// ModuleTimeout is the maximum duration of the commands of each module (0 = no limit, overridden by the timeout defined in the config)
+	ModuleTimeout time.Duration
+
+	// RunTimeout is the maximum duration of the whole -all command (0 = no limit)
+	RunTimeout time.Duration
+
+	// Attempts is the number of times the last terraform command has been executed (more than 1 if it has been retried on transient errors)
+	Attempts int
+
+	// PhaseListener is notified each time the execution of the module enters a new phase (i.e. init, command)
+	PhaseListener func(phase string)
+
+	// The current phase of the execution
+	phase string
+
+	// RunContext is used to interrupt the running commands when it is done (i.e. when a -all command is cancelled)
+	RunContext context.Context
+
+	// ReportFile is the file where the report of -all commands should be written
+	ReportFile string
+
+	// ReportFormat is the format of the report (json or junit, determined by the file extension if not specified)
+	ReportFormat string
+
+	// PlanDir is the folder where plan-all saves the plan of each module (along with a manifest)
+	PlanDir string
+
+	// FromPlansDir is the folder containing the plans saved by plan-all that apply-all must apply (the variables defined
+	// by extra_arguments are not passed to terraform since they are already in the plans)
+	FromPlansDir string
+
+	// DependencyOutputs contains the outputs of the modules referenced by the dependency blocks (by dependency name)
+	DependencyOutputs map[string]interface{}
+
+	// SkipDependencyOutputs indicates that the outputs of the dependency blocks should not be fetched while parsing the
+	// configuration (i.e. while resolving the modules of a stack, only the dependencies are relevant)
+	SkipDependencyOutputs bool
+
+	// DependencyChain contains the config files of the modules whose outputs are being fetched through the dependency
+	// blocks (the first one being the module that is executed). It is used to detect the cycles and to avoid running
+	// the hooks of the dependencies.
+	DependencyChain []string
+
+	// IncludeDirs is the list of glob patterns used to select the modules of a stack (all modules are selected if empty)
+	IncludeDirs []string
+
+	// ExcludeDirs is the list of glob patterns used to exclude modules from a stack
+	ExcludeDirs []string
+
+	// If set to true, the dependencies of the selected modules are also included in the stack
+	WithDependencies bool
+
+	// If set to true, the modules that depend on the selected modules are also included in the stack
+	WithDependents bool
+
+	// If set to true, the dependencies found by analyzing the terraform_remote_state data sources are added to the stack
+	InferDependencies bool
+
+	// If set to true, the configurations of the modules are always parsed while resolving a stack instead of using the
+	// results saved by the previous runs
+	NoDiscoveryCache bool
+
+	// If set to true, output-all prints a single JSON document containing the outputs of all modules
+	AggregateOutput bool
+
+	// If set to true, terragrunt prints what it would execute on the modules without running terraform or any hook
+	Explain bool
+
+	// ExplainFormat is the format used to print the explanations (text or json)
+	ExplainFormat string
+
+	// ExternalDependencies is the policy applied to the dependencies outside of the root folders of the stack (prompt,
+	// skip, include or fail)
+	ExternalDependencies string
+
+	// StackRoots is the list of root folders in which the modules of the stack are searched (only the working dir if empty)
+	StackRoots []string
+
+	// AllowDestroy is the list of module paths that could be destroyed even if they are protected by prevent_destroy
+	AllowDestroy []string
 }
 
 // NewTerragruntOptions creates a new TerragruntOptions object with reasonable defaults for real usage
@@ -154,6 +259,7 @@ func NewTerragruntOptions(terragruntConfigPath string) *TerragruntOptions {
 func NewTerragruntOptionsForTest(terragruntConfigPath string) *TerragruntOptions {
 	opts := NewTerragruntOptions(terragruntConfigPath)
 	opts.NonInteractive = true
+	opts.NoDiscoveryCache = true
 	return opts
 }
 
@@ -176,6 +282,14 @@ func (terragruntOptions *TerragruntOptions) ConfigPath(folder string) (string, b
 	return util.JoinPath(folder, DefaultConfigName), false
 }
 
+// StackRootFolders returns the folders in which the modules of the stack must be searched
+func (terragruntOptions *TerragruntOptions) StackRootFolders() []string {
+	if len(terragruntOptions.StackRoots) == 0 {
+		return []string{terragruntOptions.WorkingDir}
+	}
+	return terragruntOptions.StackRoots
+}
+
 // FindConfigFilesInPath returns a list of all Terragrunt config files in the given path or any subfolder of the path.
 // A file is a Terragrunt config file if it its name matches the DefaultConfigName constant and contains Terragrunt
 // config contents as returned by the IsTerragruntConfig method.
@@ -185,12 +299,22 @@ func (terragruntOptions *TerragruntOptions) FindConfigFilesInPath(rootPath strin
 	}
 	configFiles := []string{}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	// The folders matching the patterns defined in the ignore file of the root folder are excluded with all their content
+	ignoreRules, err := util.LoadIgnoreFile(filepath.Join(rootPath, IgnorePatternsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if info.IsDir() {
+			if relativePath, _ := filepath.Rel(rootPath, path); relativePath != "." && ignoreRules.Ignored(relativePath) {
+				terragruntOptions.Logger.Debugf("Folder %s ignored by %s", path, IgnorePatternsFile)
+				return filepath.SkipDir
+			}
 			if util.FileExists(filepath.Join(path, IgnoreFile)) {
 				// If we wish to exclude a directory from the *-all commands, we just
 				// have to put an empty file name terragrunt.ignore in the folder
@@ -221,6 +345,9 @@ func (terragruntOptions TerragruntOptions) Clone(terragruntConfigPath string) *T
 	newOptions.WorkingDir = filepath.Dir(terragruntConfigPath)
 	newOptions.Env = make(map[string]string, len(terragruntOptions.Env))
 	newOptions.Variables = make(map[string]Variable, len(terragruntOptions.Variables))
+	newOptions.DependencyOutputs = nil
+	newOptions.CommandWriter = nil
+	newOptions.CommandWorkingDir = ""
 
 	if newLoggerName := util.GetPathRelativeToWorkingDir(newOptions.WorkingDir); newLoggerName != "." {
 		newOptions.Logger = terragruntOptions.Logger.Child(util.GetPathRelativeToWorkingDir(newOptions.WorkingDir))
@@ -238,6 +365,15 @@ func (terragruntOptions TerragruntOptions) Clone(terragruntConfigPath string) *T
 	return &newOptions
 }
 
+// SetPhase records the current phase of the execution and notifies the listener, it returns the previous phase
+func (terragruntOptions *TerragruntOptions) SetPhase(phase string) (previous string) {
+	previous, terragruntOptions.phase = terragruntOptions.phase, phase
+	if terragruntOptions.PhaseListener != nil && phase != "" {
+		terragruntOptions.PhaseListener(phase)
+	}
+	return
+}
+
 // SetStatus saves environment variables indicating the current execution status
 func (terragruntOptions *TerragruntOptions) SetStatus(exitCode int, err error) {
 	errorMessage := fmt.Sprint(err)
@@ -272,6 +408,14 @@ func (terragruntOptions TerragruntOptions) GetContext() (result collections.IDic
 	context["TerragruntConfigPath"] = terragruntOptions.TerragruntConfigPath
 	context["WorkingDir"] = terragruntOptions.WorkingDir
 	result.Set("TerragruntOptions", context)
+
+	if len(terragruntOptions.DependencyOutputs) > 0 {
+		dependencies := make(map[string]interface{}, len(terragruntOptions.DependencyOutputs))
+		for name, outputs := range terragruntOptions.DependencyOutputs {
+			dependencies[name] = map[string]interface{}{"outputs": outputs}
+		}
+		result.Set("dependency", dependencies)
+	}
 	return
 }
 
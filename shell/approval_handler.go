@@ -1,25 +1,39 @@
 package shell
 
 import (
-	"bufio"
+	"context"
 	goErrors "errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/coveooss/terragrunt/v2/options"
 )
 
-var sharedMutex = sync.Mutex{}
+// Only one command can wait for approval at a time (the lock is released as soon as the answer is given)
+var approvalLock = make(chan bool, 1)
 
-// RunCommandToApprove runs a command with approval (expect style)
+// RunCommandToApprove runs a command with approval (expect style). The command is interrupted and the approval is
+// abandoned if the run context is done (i.e. timeout or fail fast interrupt mode).
 func RunCommandToApprove(cmd *exec.Cmd, expectedStatements []string, completedStatements []string, terragruntOptions *options.TerragruntOptions) error {
-	sharedMutex.Lock()
-	defer sharedMutex.Unlock()
+	ctx := terragruntOptions.RunContext
+	select {
+	case approvalLock <- true:
+	case <-contextDone(ctx):
+		return fmt.Errorf("interrupted while waiting for another approval: %v", ctx.Err())
+	}
+	var released bool
+	releaseApprovalLock := func() {
+		if !released {
+			released = true
+			<-approvalLock
+		}
+	}
+	defer releaseApprovalLock()
+
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return err
@@ -30,9 +44,17 @@ func RunCommandToApprove(cmd *exec.Cmd, expectedStatements []string, completedSt
 	if err != nil {
 		return err
 	}
+	// The command is interrupted if the execution is cancelled (i.e. fail fast interrupt mode) while it is waiting
+	stopInterruptHandler := interruptOnCancel(ctx, cmd, terragruntOptions.Logger)
+	defer stopInterruptHandler()
+
 	i := 0
 	for !stdOutInterceptor.WaitingForValue() && i < 30 {
-		time.Sleep(1 * time.Second)
+		if !sleepUnlessCancelled(ctx, 1*time.Second) {
+			releaseApprovalLock()
+			cmd.Wait()
+			return fmt.Errorf("interrupted while waiting for input prompt: %v", ctx.Err())
+		}
 		i++
 	}
 	if i == 30 {
@@ -44,13 +66,17 @@ func RunCommandToApprove(cmd *exec.Cmd, expectedStatements []string, completedSt
 	}
 
 	if !stdOutInterceptor.IsComplete() {
-		var text string
-		if len(terragruntOptions.ApprovalHandler) > 0 {
-			text, err = approveWithCustomHandler(terragruntOptions, stdOutInterceptor.GetBuffer())
-		} else {
-			text, err = approveInConsole()
-		}
+		text, err := waitForApproval(ctx, func() (string, error) {
+			if len(terragruntOptions.ApprovalHandler) > 0 {
+				return approveWithCustomHandler(ctx, terragruntOptions, stdOutInterceptor.GetBuffer())
+			}
+			return console.readLine(ctx)
+		})
 		if err != nil {
+			// The command is not approved, closing its input makes it stop (if it has not already been interrupted)
+			stdin.Close()
+			releaseApprovalLock()
+			cmd.Wait()
 			return err
 		}
 		io.WriteString(stdin, text)
@@ -60,6 +86,8 @@ func RunCommandToApprove(cmd *exec.Cmd, expectedStatements []string, completedSt
 		return err
 	}
 
+	// The other commands can ask for approval while this one is running
+	releaseApprovalLock()
 	err = cmd.Wait()
 	if err != nil {
 		return goErrors.New("terraform did not complete successfully")
@@ -72,12 +100,34 @@ func isDashAllQuery(terragruntArgs string) bool {
 	return strings.Contains(terragruntArgs, "-all")
 }
 
-func approveInConsole() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
-	return reader.ReadString('\n')
+// Returns the approval or an error if the context is done before it is given (the console read is abandoned)
+func waitForApproval(ctx context.Context, approve func() (string, error)) (string, error) {
+	type approval struct {
+		text string
+		err  error
+	}
+	result := make(chan approval, 1)
+	go func() {
+		text, err := approve()
+		result <- approval{text, err}
+	}()
+	select {
+	case approval := <-result:
+		return approval.text, approval.err
+	case <-contextDone(ctx):
+		return "", fmt.Errorf("interrupted while waiting for approval: %v", ctx.Err())
+	}
+}
+
+// Returns the done channel of the context (a nil channel that is never ready if there is no context)
+func contextDone(ctx context.Context) <-chan struct{} {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Done()
 }
 
-func approveWithCustomHandler(terragruntOptions *options.TerragruntOptions, prompt string) (string, error) {
+func approveWithCustomHandler(ctx context.Context, terragruntOptions *options.TerragruntOptions, prompt string) (string, error) {
 	command := strings.Split(terragruntOptions.ApprovalHandler, " ")[0]
 	command, err := LookPath(command, terragruntOptions.Env["PATH"])
 	if err != nil {
@@ -88,6 +138,9 @@ func approveWithCustomHandler(terragruntOptions *options.TerragruntOptions, prom
 		args[index] = strings.Replace(args[index], "{val}", prompt, -1)
 	}
 	approvalCmd := exec.Command(command, args...)
+	if ctx != nil {
+		approvalCmd = exec.CommandContext(ctx, command, args...)
+	}
 	approvalCmd.Stderr = os.Stderr
 	resp, err := approvalCmd.Output()
 
@@ -2,9 +2,12 @@ package shell
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/coveooss/terragrunt/v2/tgerrors"
@@ -22,9 +25,7 @@ func promptUserForInput(prompt string, terragruntOptions *options.TerragruntOpti
 		return "yes", nil
 	}
 
-	reader := bufio.NewReader(os.Stdin)
-
-	text, err := reader.ReadString('\n')
+	text, err := console.readLine(nil)
 	if err != nil {
 		return "", tgerrors.WithStackTrace(err)
 	}
@@ -47,3 +48,49 @@ func PromptUserForYesNo(prompt string, terragruntOptions *options.TerragruntOpti
 		return false, nil
 	}
 }
+
+// The console input is read by a single long-lived reader. The lines are only handed to the prompt that is currently
+// waiting for them, so a prompt that is abandoned (i.e. on timeout) doesn't consume the answer given to the next one.
+var console = newConsoleReader(os.Stdin)
+
+type consoleLine struct {
+	text string
+	err  error
+}
+
+type consoleReader struct {
+	input io.Reader
+	once  sync.Once
+	lines chan consoleLine
+}
+
+func newConsoleReader(input io.Reader) *consoleReader {
+	return &consoleReader{input: input, lines: make(chan consoleLine)}
+}
+
+// Returns the next line entered in the console or an error if the context is done before a line is entered
+func (console *consoleReader) readLine(ctx context.Context) (string, error) {
+	console.once.Do(func() {
+		go func() {
+			reader := bufio.NewReader(console.input)
+			for {
+				text, err := reader.ReadString('\n')
+				console.lines <- consoleLine{text, err}
+				if err != nil {
+					close(console.lines)
+					return
+				}
+			}
+		}()
+	})
+
+	select {
+	case line, ok := <-console.lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return line.text, line.err
+	case <-contextDone(ctx):
+		return "", fmt.Errorf("interrupted while waiting for console input: %v", ctx.Err())
+	}
+}
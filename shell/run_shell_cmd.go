@@ -2,6 +2,7 @@ package shell
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,9 +11,11 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/utils"
@@ -39,6 +42,35 @@ type CommandContext struct {
 	env                 []string
 	workingDir          string
 	retries             int
+	retryPolicy         *RetryPolicy
+}
+
+// RetryPolicy describes the transient errors on which a failing command should be executed again
+type RetryPolicy struct {
+	RetryableErrors []*regexp.Regexp // The command is retried only if its error output or its error matches one of these expressions
+	MaxAttempts     int              // The maximum number of times the command is executed
+	Backoff         time.Duration    // The delay before the first retry (doubled on each subsequent retry)
+}
+
+// Returns the delay to wait before executing the command again or false if the command should not be retried.
+// Only the error output is considered since the regular output may legitimately contain the same words (i.e. a plan).
+func (policy *RetryPolicy) shouldRetry(attempt int, err error, errOutput string) (time.Duration, bool) {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return 0, false
+	}
+	if _, planStatus := tgerrors.Unwrap(err).(tgerrors.PlanWithChanges); planStatus {
+		return 0, false
+	}
+	if exitCode, convErr := GetExitCode(err); convErr == nil && exitCode == tgerrors.ChangeExitCode {
+		// The exit code 2 is returned by terraform plan -detailed-exitcode when there are changes, it is not a failure
+		return 0, false
+	}
+	for _, retryableError := range policy.RetryableErrors {
+		if retryableError.MatchString(errOutput) || retryableError.MatchString(err.Error()) {
+			return policy.Backoff * time.Duration(1<<uint(attempt-1)), true
+		}
+	}
+	return 0, false
 }
 
 // NewCmd initializes the ShellCommand object
@@ -92,6 +124,12 @@ func (c *CommandContext) WithRetries(retries int) *CommandContext {
 	return c
 }
 
+// WithRetryPolicy instructs to execute the command again if it fails with an error matching the policy
+func (c *CommandContext) WithRetryPolicy(policy *RetryPolicy) *CommandContext {
+	c.retryPolicy = policy
+	return c
+}
+
 // WorkingDir changes the default working directory for the command
 func (c *CommandContext) WorkingDir(wd string) *CommandContext {
 	c.workingDir = wd
@@ -119,8 +157,14 @@ func (c CommandContext) LogOutput(logLevel logrus.Level) error {
 
 // Run executes the command
 func (c CommandContext) Run() error {
+	_, err := c.RunWithAttempts()
+	return err
+}
+
+// RunWithAttempts executes the command and returns the number of times it has been executed
+func (c CommandContext) RunWithAttempts() (attempts int, err error) {
 	if c.options == nil {
-		return tgerrors.WithStackTrace(fmt.Errorf("options not configured for command"))
+		return 0, tgerrors.WithStackTrace(fmt.Errorf("options not configured for command"))
 	}
 
 	// If the output is captured, we use a different logging level
@@ -129,9 +173,10 @@ func (c CommandContext) Run() error {
 
 	if c.command == c.options.TerraformPath {
 		const noColor = "-no-color"
-		if util.ListContainsElement(c.options.TerraformCliArgs, noColor) {
-			// If the user specified -no-color, we should respect it in intermediate calls too
-			c.args = append(c.args, noColor)
+		if util.ListContainsElement(c.options.TerraformCliArgs, noColor) && len(c.args) > 0 && !util.ListContainsElement(collections.ToStrings(c.args), noColor) {
+			// If the user specified -no-color, we should respect it in intermediate calls too. The option is inserted
+			// right after the sub command since terraform does not accept options after the positional arguments.
+			c.args = append(c.args[:1:1], append([]interface{}{noColor}, c.args[1:]...)...)
 		}
 		// Terragrunt can run some commands (such as terraform remote config) before running the actual terraform
 		// command requested by the user. The output of these other commands should not end up on stdout as this
@@ -154,22 +199,30 @@ func (c CommandContext) Run() error {
 	}
 
 	var finalStatus error
-	for try := 0; try <= c.retries; try++ {
+	for try := 0; ; try++ {
+		if c.options.RunContext != nil && c.options.RunContext.Err() != nil {
+			// The execution has been cancelled, there is no need to start the command
+			return try, tgerrors.WithStackTrace(errCommandCancelled{c.options.RunContext.Err()})
+		}
+
 		cmd, tempFile, err := utils.GetCommandFromString(c.command, c.args...)
 		if err != nil {
-			return tgerrors.WithStackTrace(err)
+			return try, tgerrors.WithStackTrace(err)
 		}
 
 		if cmd.Args[0], err = LookPath(cmd.Args[0], c.options.Env["PATH"]); err != nil {
-			return tgerrors.WithStackTrace(err)
+			return try, tgerrors.WithStackTrace(err)
 		}
 
+		attempts = try + 1
 		verb := "Running "
 		if try > 0 {
 			verb = fmt.Sprintf("Trying(#%d)", try+1)
-			// On subsequent retry, we ignore the output to avoid displaying the same output many times
-			// TODO, check if the output is the same as the previous one to catch different messages
-			c.Stdout, c.Stderr = nil, nil
+			if try <= c.retries {
+				// On subsequent retry, we ignore the output to avoid displaying the same output many times
+				// TODO, check if the output is the same as the previous one to catch different messages
+				c.Stdout, c.Stderr = nil, nil
+			}
 		}
 
 		if c.DisplayCommand == "" {
@@ -187,6 +240,11 @@ func (c CommandContext) Run() error {
 
 		cmd.Stdout, cmd.Stderr, cmd.Env = c.Stdout, c.Stderr, c.env
 		cmd.Dir = c.options.WorkingDir
+		errOutput := new(bytes.Buffer)
+		if c.retryPolicy != nil {
+			// We keep a copy of the error output to determine if the error is retryable
+			cmd.Stdout, cmd.Stderr = teeErrorOutput(c.Stdout, c.Stderr, errOutput)
+		}
 		cmdChannel := make(chan error)
 
 		signalChannel := NewSignalsForwarder(forwardSignals, cmd, c.log, cmdChannel)
@@ -196,18 +254,32 @@ func (c CommandContext) Run() error {
 			finalStatus = RunCommandToApprove(cmd, c.expectedStatements, c.completedStatements, c.options)
 		} else {
 			cmd.Stdin = os.Stdin
-			finalStatus = cmd.Run()
+			if finalStatus = cmd.Start(); finalStatus == nil {
+				stopInterruptHandler := interruptOnCancel(c.options.RunContext, cmd, c.log)
+				finalStatus = cmd.Wait()
+				stopInterruptHandler()
+			}
 		}
 
 		cmdChannel <- finalStatus
 		if finalStatus == nil {
 			break
-		} else {
-			c.log.Debugf("Caught error on command: %v", finalStatus)
+		}
+		c.log.Debugf("Caught error on command: %v", finalStatus)
+		if try < c.retries {
+			continue
+		}
+		delay, retry := c.retryPolicy.shouldRetry(try+1, finalStatus, errOutput.String())
+		if !retry {
+			break
+		}
+		c.log.Warningf("%s failed with a retryable error (attempt %d/%d), retrying in %v", c.DisplayCommand, try+1, c.retryPolicy.MaxAttempts, delay)
+		if !sleepUnlessCancelled(c.options.RunContext, delay) {
+			break
 		}
 	}
 
-	return tgerrors.WithStackTrace(finalStatus)
+	return attempts, tgerrors.WithStackTrace(finalStatus)
 }
 
 // LookPath search the supplied path to find the desired command
@@ -290,4 +362,95 @@ func (signalChannel *SignalsForwarder) Close() error {
 	return nil
 }
 
+// InterruptGracePeriod is the delay given to a command to terminate after having been interrupted before it is killed
+var InterruptGracePeriod = 30 * time.Second
+
+// Send an interrupt signal to the command when the context is done and kill it if it is still running after the
+// grace period. The returned function must be called when the command is completed.
+func interruptOnCancel(ctx context.Context, cmd *exec.Cmd, logger *multilogger.Logger) (stop func()) {
+	if ctx == nil {
+		return func() {}
+	}
+
+	completed := make(chan bool)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-completed:
+			return
+		}
+
+		logger.Warningf("Interrupting %s (%v)", filepath.Base(cmd.Path), ctx.Err())
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			logger.Debugf("Unable to interrupt the process, killing it: %v", err)
+			cmd.Process.Kill()
+			return
+		}
+
+		select {
+		case <-time.After(InterruptGracePeriod):
+			logger.Errorf("%s is still running %v after having been interrupted, killing it", filepath.Base(cmd.Path), InterruptGracePeriod)
+			cmd.Process.Kill()
+		case <-completed:
+		}
+	}()
+
+	return func() { close(completed) }
+}
+
+// Wait for the delay and returns false if the context has been cancelled in the meantime
+func sleepUnlessCancelled(ctx context.Context, delay time.Duration) bool {
+	if ctx == nil {
+		time.Sleep(delay)
+		return true
+	}
+	select {
+	case <-time.After(delay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Returns the writers that copy the command error output to the original writer and to the buffer. If both writers are
+// the same, the writes are serialized since exec.Cmd only does it when stdout and stderr are the same writer.
+func teeErrorOutput(stdout, stderr io.Writer, buffer io.Writer) (io.Writer, io.Writer) {
+	if stderr == nil {
+		return stdout, buffer
+	}
+	if sameWriter(stdout, stderr) {
+		shared := &syncWriter{writer: stdout}
+		return shared, io.MultiWriter(shared, buffer)
+	}
+	return stdout, io.MultiWriter(stderr, buffer)
+}
+
+// Compares the writers without panicking if their type is not comparable
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() { recover() }()
+	return a == b
+}
+
+// A writer that can safely be written by both the stdout and stderr of a command
+type syncWriter struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writer.Write(p)
+}
+
 var iif = collections.IIf
+
+// Custom error types
+
+type errCommandCancelled struct {
+	cause error
+}
+
+func (err errCommandCancelled) Error() string {
+	return fmt.Sprintf("Command not started: %v", err.cause)
+}
@@ -1,7 +1,10 @@
 package shell
 
 import (
+	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/coveooss/terragrunt/v2/options"
 	"github.com/stretchr/testify/assert"
@@ -18,3 +21,23 @@ func TestRunShellCommand(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, value, `Terraform has no command named "not-a-real-command"`)
 }
+
+func TestApprovalInConsoleAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	input, writer := io.Pipe()
+	defer writer.Close()
+	console := newConsoleReader(input)
+
+	// The first prompt times out without an answer
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := waitForApproval(ctx, func() (string, error) { return console.readLine(ctx) })
+	assert.Error(t, err)
+
+	// The answer is given to the second prompt and not consumed by the abandoned one
+	go io.WriteString(writer, "yes\n")
+	text, err := waitForApproval(context.Background(), func() (string, error) { return console.readLine(context.Background()) })
+	assert.NoError(t, err)
+	assert.Equal(t, "yes\n", text)
+}
@@ -3,14 +3,18 @@
 package shell
 
 import (
+	"context"
 	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/coveooss/terragrunt/v2/options"
+	"github.com/coveooss/terragrunt/v2/tgerrors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,3 +118,104 @@ func TestNewSignalsForwarderMultipleUnix(t *testing.T) {
 	assert.True(t, retCode <= interrupts, "Subprocess received wrong number of signals")
 	assert.Equal(t, retCode, expectedInterrupts, "Subprocess didn't receive multiple signals")
 }
+
+func TestRunShellCommandInterruptedByContextUnix(t *testing.T) {
+	t.Parallel()
+
+	terragruntOptions := options.NewTerragruntOptionsForTest("")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	terragruntOptions.RunContext = ctx
+
+	start := time.Now()
+	err := NewCmd(terragruntOptions, "sleep").Args("30").Run()
+	assert.Error(t, err)
+	assert.WithinDuration(t, start.Add(500*time.Millisecond), time.Now(), 2*time.Second, "Expected the command to be interrupted")
+
+	// Once the context is done, the command should not even be started
+	err = NewCmd(terragruntOptions, "sleep").Args("30").Run()
+	assert.IsType(t, errCommandCancelled{}, tgerrors.Unwrap(err))
+}
+
+func TestRunShellCommandWithRetryPolicyUnix(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{
+		RetryableErrors: []*regexp.Regexp{regexp.MustCompile("(?i)throttling")},
+		MaxAttempts:     3,
+		Backoff:         10 * time.Millisecond,
+	}
+
+	testCases := []struct {
+		name             string
+		failures         int
+		message          string
+		exitCode         string
+		stream           string
+		expectedAttempts int
+		expectedError    bool
+	}{
+		{"no error", 0, "Throttling", "1", "stderr", 1, false},
+		{"retryable error", 2, "Error: Throttling: Rate exceeded", "1", "stderr", 3, false},
+		{"too many retryable errors", 5, "Error: Throttling: Rate exceeded", "1", "stderr", 3, true},
+		{"non retryable error", 2, "Error: Invalid resource", "1", "stderr", 1, true},
+		{"retryable message in stdout", 2, "aws_api_gateway_usage_plan.throttling will be updated", "1", "stdout", 1, true},
+		{"plan with changes", 2, "Error: Throttling: Rate exceeded", "2", "stderr", 1, true},
+	}
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			terragruntOptions := options.NewTerragruntOptionsForTest("")
+			counterFile := filepath.Join(t.TempDir(), "counter")
+			cmd := NewCmd(terragruntOptions, "../testdata/test_transient_error.sh").Args(counterFile, strconv.Itoa(tt.failures), tt.message, tt.exitCode, tt.stream)
+			attempts, err := cmd.WithRetryPolicy(policy).RunWithAttempts()
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			assert.Equal(t, tt.expectedError, err != nil)
+		})
+	}
+}
+
+func TestRunCommandToApproveInterruptedByContextUnix(t *testing.T) {
+	t.Parallel()
+
+	expected, completed := []string{"Enter a value:"}, []string{"Apply complete!"}
+
+	// The approval is given by the custom handler
+	terragruntOptions := options.NewTerragruntOptionsForTest("")
+	terragruntOptions.ApprovalHandler = "echo yes"
+	assert.NoError(t, NewCmd(terragruntOptions, "../testdata/test_approval.sh").Expect(expected, completed).Run())
+
+	// The approval is abandoned when the module times out
+	terragruntOptions = options.NewTerragruntOptionsForTest("")
+	terragruntOptions.ApprovalHandler = "sleep 30"
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	terragruntOptions.RunContext = ctx
+
+	start := time.Now()
+	err := NewCmd(terragruntOptions, "../testdata/test_approval.sh").Expect(expected, completed).Run()
+	assert.Error(t, err)
+	assert.WithinDuration(t, start.Add(1500*time.Millisecond), time.Now(), 2*time.Second, "Expected the approval to be interrupted")
+}
+
+func TestRunCommandToApproveReleasesLockOnceApprovedUnix(t *testing.T) {
+	t.Parallel()
+
+	expected, completed := []string{"Enter a value:"}, []string{"Apply complete!"}
+	run := func(delay string, done chan<- string) {
+		terragruntOptions := options.NewTerragruntOptionsForTest("")
+		terragruntOptions.ApprovalHandler = "echo yes"
+		assert.NoError(t, NewCmd(terragruntOptions, "../testdata/test_approval.sh").Args(delay).Expect(expected, completed).Run())
+		done <- delay
+	}
+
+	// The second command is approved and completes while the first one is still running after its approval
+	done := make(chan string, 2)
+	go run("5", done)
+	time.Sleep(100 * time.Millisecond)
+	go run("0", done)
+	assert.Equal(t, "0", <-done, "The second command should not wait for the first one to complete")
+	assert.Equal(t, "5", <-done)
+}
@@ -0,0 +1,8 @@
+#!/bin/bash -e
+
+# Asks for an approval and completes once it has been given (after the optional delay in seconds)
+echo "Do you want to perform these actions?"
+echo "  Enter a value: "
+read answer
+sleep "${1:-0}"
+echo "Apply complete! ($answer)"
@@ -0,0 +1,21 @@
+#!/bin/bash -e
+
+# Fails with the specified message (and exit code) until the command has been called the specified number of times
+counter_file=$1
+failures=$2
+message=$3
+exit_code=${4:-1}
+stream=${5:-stderr}
+
+count=$(($(cat "$counter_file" 2>/dev/null || echo 0) + 1))
+echo $count > "$counter_file"
+
+if [ $count -le $failures ]; then
+    if [ "$stream" == "stdout" ]; then
+        echo "$message"
+    else
+        echo "$message" >&2
+    fi
+    exit $exit_code
+fi
+echo "Succeeded after $count attempt(s)"
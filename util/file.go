@@ -227,7 +227,9 @@ func GetSource(source, pwd string, logger *multilogger.Logger, fileRegex string)
 			}
 			logf(logrus.WarnLevel, "Downloading %s failed. Retrying in 1 second. Err: %v", source, err)
 			time.Sleep(time.Second)
+			sharedMutex.Lock()
 			delete(sharedContent, result)
+			sharedMutex.Unlock()
 			if result != "" && FileExists(result) {
 				// Download failed but the dir exists, let's delete it
 				logf(logrus.WarnLevel, "Deleting cache dir for %s: %s", source, result)
@@ -389,6 +391,26 @@ func WriteFileWithSamePermissions(source string, destination string, contents []
 	return ioutil.WriteFile(destination, contents, fileInfo.Mode())
 }
 
+// LockFile takes an exclusive advisory lock on the given lock file (created if it doesn't exist) to synchronize the
+// processes that update a shared file. It waits until the lock is available and returns the function that releases it.
+func LockFile(path string) (unlock func(), err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
 // JoinPath always use / as the separator.
 // Windows systems use \ as the path separator *nix uses /
 // Use this function when joining paths to force the returned path to use / as the path separator
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/coveooss/terragrunt/v2/tgerrors"
+)
+
+// MatchGlob returns true if the path matches the glob pattern. In addition to the patterns supported by filepath.Match,
+// a ** segment matches zero or more folders (i.e. a/**/b matches a/b, a/x/b and a/x/y/b).
+func MatchGlob(pattern, path string) bool {
+	pattern, path = cleanGlobPath(pattern), cleanGlobPath(path)
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+// MatchGlobOrParent returns true if the path or one of its parent folders matches the glob pattern
+func MatchGlobOrParent(pattern, path string) bool {
+	for path = cleanGlobPath(path); ; {
+		if MatchGlob(pattern, path) {
+			return true
+		}
+		index := strings.LastIndex(path, "/")
+		if index < 0 {
+			return false
+		}
+		path = path[:index]
+	}
+}
+
+func cleanGlobPath(value string) string {
+	return strings.Trim(path.Clean(filepath.ToSlash(value)), "/")
+}
+
+func matchSegments(patterns, segments []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			// We try to match the remaining patterns with every possible suffix of the path
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(patterns[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, err := path.Match(patterns[0], segments[0]); err != nil || !matched {
+			return false
+		}
+		patterns, segments = patterns[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// IgnoreRules represents a list of gitignore-style patterns
+type IgnoreRules []ignoreRule
+
+type ignoreRule struct {
+	pattern string
+	negate  bool
+}
+
+// ParseIgnoreRules parses gitignore-style patterns (one per line). Blank lines and lines starting with # are ignored,
+// patterns starting with ! re-include previously ignored paths and patterns that do not contain a / (except a trailing
+// one) match at any level.
+func ParseIgnoreRules(content string) (rules IgnoreRules) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		line = strings.TrimSuffix(line, "/")
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+	return
+}
+
+// LoadIgnoreFile reads the gitignore-style patterns from the given file (no rules are returned if the file does not exist)
+func LoadIgnoreFile(filename string) (IgnoreRules, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, tgerrors.WithStackTrace(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return ParseIgnoreRules(strings.Join(lines, "\n")), tgerrors.WithStackTrace(scanner.Err())
+}
+
+// Ignored returns true if the path (relative to the folder containing the rules) is ignored. As with gitignore, the last
+// matching rule wins.
+func (rules IgnoreRules) Ignored(path string) (ignored bool) {
+	for _, rule := range rules {
+		if MatchGlob(rule.pattern, path) {
+			ignored = !rule.negate
+		}
+	}
+	return
+}
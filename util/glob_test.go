@@ -0,0 +1,92 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"a", "a", true},
+		{"a", "b", false},
+		{"a/*", "a/b", true},
+		{"a/*", "a/b/c", false},
+		{"a/**", "a/b/c", true},
+		{"a/**", "a", true},
+		{"**/c", "a/b/c", true},
+		{"**/c", "c", true},
+		{"a/**/c", "a/c", true},
+		{"a/**/c", "a/b/x/c", true},
+		{"a/**/c", "a/b/x/d", false},
+		{"prod-*/vpc", "prod-us/vpc", true},
+		{"prod-*/vpc", "dev-us/vpc", false},
+		{"./a/b/", "a/b", true},
+		{"a/b/", "a/b", true},
+	}
+
+	for _, testCase := range testCases {
+		actual := MatchGlob(testCase.pattern, testCase.path)
+		assert.Equal(t, testCase.expected, actual, "For pattern %s and path %s", testCase.pattern, testCase.path)
+	}
+}
+
+func TestMatchGlobOrParent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"a", "a/b/c", true},
+		{"a/b", "a/b/c", true},
+		{"a/c", "a/b/c", false},
+		{"*/b", "a/b/c", true},
+		{"b", "a/b/c", false},
+	}
+
+	for _, testCase := range testCases {
+		actual := MatchGlobOrParent(testCase.pattern, testCase.path)
+		assert.Equal(t, testCase.expected, actual, "For pattern %s and path %s", testCase.pattern, testCase.path)
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	t.Parallel()
+
+	rules := ParseIgnoreRules(`
+# Comment
+tmp/
+/legacy
+modules/**/test
+*.bak
+!keep.bak
+`)
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"tmp", true},
+		{"a/b/tmp", true},
+		{"legacy", true},
+		{"a/legacy", false},
+		{"modules/test", true},
+		{"modules/x/y/test", true},
+		{"modules/x/y", false},
+		{"folder.bak", true},
+		{"a/keep.bak", false},
+		{"Comment", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, rules.Ignored(testCase.path), "For path %s", testCase.path)
+	}
+}
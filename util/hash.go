@@ -3,6 +3,10 @@ package util
 import (
 	"crypto/sha1"
 	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // EncodeBase64Sha1 returns the base 64 encoded sha1 hash of the given string
@@ -10,3 +14,36 @@ func EncodeBase64Sha1(str string) string {
 	hash := sha1.Sum([]byte(str))
 	return base64.RawURLEncoding.EncodeToString(hash[:])
 }
+
+// EncodeBase64Sha1Folder returns the base 64 encoded sha1 hash of the name and the content of the files in the given folder.
+// The hidden files and folders (such as .terraform or .terragrunt-cache) are ignored.
+func EncodeBase64Sha1Folder(folder string) (string, error) {
+	hash := sha1.New()
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != folder && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relativePath, _ := filepath.Rel(folder, path)
+		hash.Write([]byte(filepath.ToSlash(relativePath) + "\x00"))
+		hash.Write(content)
+		hash.Write([]byte("\x00"))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeBase64Sha1Folder(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "hash")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+
+	write := func(name, content string) {
+		path := filepath.Join(folder, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	hash := func() string {
+		result, err := EncodeBase64Sha1Folder(folder)
+		assert.NoError(t, err)
+		return result
+	}
+
+	write("main.tf", "resource {}")
+	write("sub/variables.tf", "variable {}")
+	initial := hash()
+
+	// Hidden files and folders are ignored
+	write(".terraform/plugins/provider", "binary")
+	write(".terraform.lock.hcl", "lock")
+	assert.Equal(t, initial, hash())
+
+	write("sub/variables.tf", "variable \"x\" {}")
+	assert.NotEqual(t, initial, hash())
+
+	_, err = EncodeBase64Sha1Folder(filepath.Join(folder, "missing"))
+	assert.Error(t, err)
+}
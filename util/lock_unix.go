@@ -0,0 +1,17 @@
+// +build linux darwin
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// Take an exclusive lock on the file (blocks until the lock is available)
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
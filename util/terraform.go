@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/terraform/configs"
+	"github.com/zclconf/go-cty/cty"
 
 	"github.com/coveooss/gotemplate/v3/collections"
 	"github.com/coveooss/gotemplate/v3/json"
@@ -225,6 +227,86 @@ func loadDefaultValues(folder string, retry bool, logger *multilogger.Logger) (m
 	return importedVariables, terraformConfig.Variables, err
 }
 
+// RemoteStateReference is a terraform_remote_state data source found in the terraform files of a module
+type RemoteStateReference struct {
+	Name    string
+	File    string // The terraform file that declares the data source
+	Backend string
+	Config  map[string]interface{}
+}
+
+// LoadRemoteStateReferences returns the terraform_remote_state data sources defined in the terraform files of the folder.
+// The variables (and the default values of the variables declared in the folder) are used to evaluate the backend
+// configuration. The attributes that cannot be evaluated (i.e. referencing locals or other resources) are ignored.
+func LoadRemoteStateReferences(folder string, variables map[string]interface{}) ([]RemoteStateReference, error) {
+	module, diag := configs.NewParser(nil).LoadConfigDir(folder)
+	if module == nil {
+		return nil, convertHclError(diag)
+	}
+
+	values := make(map[string]interface{}, len(module.Variables)+len(variables))
+	for name, variable := range module.Variables {
+		var value interface{}
+		if !variable.Default.IsNull() && FromCtyValue(variable.Default, &value) == nil {
+			values[name] = value
+		}
+	}
+	for name, value := range variables {
+		values[name] = value
+	}
+	evalContext := &hcl.EvalContext{Variables: map[string]cty.Value{}}
+	if ctyValues, err := ToCtyValue(values); err == nil {
+		evalContext.Variables["var"] = *ctyValues
+	}
+
+	var references []RemoteStateReference
+	for _, resource := range module.DataResources {
+		if resource.Type != "terraform_remote_state" {
+			continue
+		}
+		content, _, _ := resource.Config.PartialContent(&hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "backend"}, {Name: "config"}}})
+		reference := RemoteStateReference{Name: resource.Name, File: resource.DeclRange.Filename}
+		if attribute, found := content.Attributes["backend"]; found {
+			if value, diag := attribute.Expr.Value(evalContext); !diag.HasErrors() && value.IsWhollyKnown() && value.Type() == cty.String {
+				reference.Backend = value.AsString()
+			}
+		}
+		if attribute, found := content.Attributes["config"]; found {
+			reference.Config = evaluateKnownAttributes(attribute.Expr, evalContext)
+		}
+		if reference.Backend != "" && len(reference.Config) > 0 {
+			references = append(references, reference)
+		}
+	}
+	sort.Slice(references, func(i, j int) bool { return references[i].Name < references[j].Name })
+	return references, nil
+}
+
+// Evaluate the attributes of an object expression, the attributes that cannot be evaluated are ignored
+func evaluateKnownAttributes(expr hcl.Expression, evalContext *hcl.EvalContext) map[string]interface{} {
+	result := make(map[string]interface{})
+	items, diag := hcl.ExprMap(expr)
+	if diag.HasErrors() {
+		// The expression is not an object constructor (i.e. a variable), so we try to evaluate it as a whole
+		if value, diag := expr.Value(evalContext); !diag.HasErrors() && value.IsWhollyKnown() {
+			_ = FromCtyValue(value, &result)
+		}
+		return result
+	}
+	for _, item := range items {
+		key, keyDiag := item.Key.Value(nil)
+		value, valueDiag := item.Value.Value(evalContext)
+		if keyDiag.HasErrors() || valueDiag.HasErrors() || key.Type() != cty.String || !value.IsWhollyKnown() {
+			continue
+		}
+		var goValue interface{}
+		if FromCtyValue(value, &goValue) == nil {
+			result[key.AsString()] = goValue
+		}
+	}
+	return result
+}
+
 func isOverride(filename string) bool {
 	return path.Base(filename) == "override.tf" ||
 		path.Base(filename) == "override.tf.json" ||
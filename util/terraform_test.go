@@ -1,6 +1,9 @@
 package util
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,3 +51,45 @@ func TestLoadDefaultValues(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadRemoteStateReferences(t *testing.T) {
+	t.Parallel()
+
+	folder, err := ioutil.TempDir("", "remote_state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(folder)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(folder, "main.tf"), []byte(`
+		variable "bucket" {
+			default = "default-bucket"
+		}
+		variable "env" {}
+		locals {
+			region = "us-east-1"
+		}
+		data "terraform_remote_state" "vpc" {
+			backend = "s3"
+			config = {
+				bucket = var.bucket
+				key    = "${var.env}/vpc/terraform.tfstate"
+				region = local.region
+			}
+		}
+		data "terraform_remote_state" "unknown" {
+			backend = "s3"
+			config  = local.config
+		}
+		data "aws_region" "current" {}
+	`), 0644))
+
+	references, err := LoadRemoteStateReferences(folder, map[string]interface{}{"env": "dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, []RemoteStateReference{{
+		Name:    "vpc",
+		File:    filepath.Join(folder, "main.tf"),
+		Backend: "s3",
+		Config:  map[string]interface{}{"bucket": "default-bucket", "key": "dev/vpc/terraform.tfstate"},
+	}}, references)
+
+	_, err = LoadRemoteStateReferences(filepath.Join(folder, "invalid"), nil)
+	assert.Error(t, err)
+}